@@ -28,10 +28,12 @@ type HelmChartSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Chart name"
 	Name string `json:"name"`
 
-	// Versions represents the list of chart versions
-	// +kubebuilder:validation:Required
+	// Versions represents the list of chart versions.
+	// Deprecated: versions are represented by HelmChartVersion resources and this field is cleared when the chart
+	// is next synchronized
+	// +kubebuilder:validation:Optional
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Chart versions"
-	Versions []HelmChartVersion `json:"versions"`
+	Versions []HelmChartVersionSpec `json:"versions,omitempty"`
 
 	// RepositoryName represents the name of the repository
 	// +kubebuilder:validation:Required
@@ -47,17 +49,110 @@ type HelmChartSpec struct {
 // HelmChartStatus defines the observed state of HelmChart
 type HelmChartStatus struct {
 
-	// LastUpdateTimestamp represents the time the resource was last updated
+	// LastUpdateTimestamp represents the time the content of the chart last changed
 	// +kubebuilder:validation:Optional
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last Update Time"
 	LastUpdateTimestamp *metav1.Time `json:"lastUpdateTimestamp,omitempty"`
+
+	// LastCheckedTimestamp represents the time the chart was last verified against the repository index, including
+	// synchronizations of an unmodified index. It is refreshed at most once a day while the chart has not changed
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last Checked Time"
+	LastCheckedTimestamp *metav1.Time `json:"lastCheckedTimestamp,omitempty"`
+
+	// LatestVersion represents the highest version of the chart, including prereleases
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Latest Version"
+	LatestVersion string `json:"latestVersion,omitempty"`
+
+	// LatestStableVersion represents the highest version of the chart that is not a prerelease
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Latest Stable Version"
+	LatestStableVersion string `json:"latestStableVersion,omitempty"`
+
+	// LatestAppVersion represents the application version of the latest stable version, or of the latest version
+	// when the chart only contains prereleases
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Latest Application Version"
+	LatestAppVersion string `json:"latestAppVersion,omitempty"`
+
+	// VersionCount represents the number of versions of the chart
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Version Count"
+	VersionCount int `json:"versionCount,omitempty"`
+
+	// LatestCreated represents the most recent creation time of the versions of the chart
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Latest Creation Time"
+	LatestCreated *metav1.Time `json:"latestCreated,omitempty"`
+
+	// Conditions represents the observed conditions of the chart
+	// +kubebuilder:validation:Optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+const (
+	// HelmChartOrphaned indicates the chart is no longer synchronized from the index of its repository
+	HelmChartOrphaned = "Orphaned"
+
+	// HelmChartOrphanedReason is the reason used when a chart has been removed from the repository index
+	HelmChartOrphanedReason = "RemovedFromIndex"
+
+	// HelmChartExcludedReason is the reason used when a chart of the repository index is excluded by chart filters or
+	// hidden as deprecated
+	HelmChartExcludedReason = "ExcludedFromIndex"
+
+	// HelmChartPresentInIndexReason is the reason used when an orphaned chart is present in the repository index again
+	HelmChartPresentInIndexReason = "PresentInIndex"
+
+	// HelmChartRepositoryDisabled indicates the repository the chart originates from has been disabled
+	HelmChartRepositoryDisabled = "RepositoryDisabled"
+
+	// HelmChartRepositoryDisabledReason is the reason used when the repository of a chart has been disabled
+	HelmChartRepositoryDisabledReason = "RepositoryDisabled"
+
+	// HelmChartRepositoryEnabledReason is the reason used when the repository of a chart has been enabled again
+	HelmChartRepositoryEnabledReason = "RepositoryEnabled"
+
+	// HelmChartDeprecated indicates the latest version of the chart has been marked as deprecated
+	HelmChartDeprecated = "Deprecated"
+
+	// HelmChartLatestVersionDeprecatedReason is the reason used when the latest version of the chart is deprecated
+	HelmChartLatestVersionDeprecatedReason = "LatestVersionDeprecated"
+
+	// HelmChartLatestVersionSupportedReason is the reason used when the latest version of the chart is not deprecated
+	HelmChartLatestVersionSupportedReason = "LatestVersionSupported"
+)
+
+func (h *HelmChart) GetConditions() []metav1.Condition {
+	return h.Status.Conditions
+}
+
+func (h *HelmChart) SetConditions(conditions []metav1.Condition) {
+	h.Status.Conditions = conditions
+}
+
+func (h *HelmChart) GetHelmChartSpec() *HelmChartSpec {
+	return &h.Spec
+}
+
+func (h *HelmChart) GetHelmChartStatus() *HelmChartStatus {
+	return &h.Status
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Repository",type=string,JSONPath=".spec.repositoryName",description="Chart Repository"
 // +kubebuilder:printcolumn:name="Name",type=string,JSONPath=".spec.name",description="Chart Name"
-// +kubebuilder:printcolumn:name="Latest Version",type=string,JSONPath=".spec.versions[*].version",description="Latest Chart Version"
+// +kubebuilder:printcolumn:name="Latest Version",type=string,JSONPath=".status.latestVersion",description="Latest Chart Version"
+// +kubebuilder:printcolumn:name="Latest Stable",type=string,JSONPath=".status.latestStableVersion",description="Latest Stable Chart Version"
+// +kubebuilder:printcolumn:name="App Version",type=string,JSONPath=".status.latestAppVersion",description="Application Version of the Latest Chart Version"
+// +kubebuilder:printcolumn:name="Versions",type=integer,JSONPath=".status.versionCount",description="Number of Chart Versions"
 // +kubebuilder:resource:path=helmcharts,scope=Cluster
 
 // HelmChart is the Schema for the helmcharts API
@@ -78,7 +173,18 @@ type HelmChartList struct {
 	Items           []HelmChart `json:"items"`
 }
 
-type HelmChartVersion struct {
+// HelmChartVersionSpec defines a version of a chart
+type HelmChartVersionSpec struct {
+
+	// ChartName represents the name of the chart the version belongs to
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Chart name"
+	ChartName string `json:"chartName,omitempty"`
+
+	// RepositoryName represents the name of the repository the version belongs to
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Repository name"
+	RepositoryName string `json:"repositoryName,omitempty"`
 
 	// Version represents the version of the chart
 	// +kubebuilder:validation:Optional
@@ -150,10 +256,22 @@ type HelmChartVersion struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Chart URL's"
 	URLs []string `json:"urls,omitempty"`
 
+	// PassCredentials indicates that the credentials of the repository are sent when downloading the chart from its
+	// URLs. Credentials are sent to URLs on the repository host, and to URLs on other hosts only when the repository
+	// opts in using the pass-credentials annotation, following the pass_credentials_all setting of Helm
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Pass credentials"
+	PassCredentials bool `json:"passCredentials,omitempty"`
+
 	// KubeVersion is a SemVer constraint specifying the version of Kubernetes required.
 	// +kubebuilder:validation:Optional
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Applicable Kubernetes version"
 	KubeVersion string `json:"kubeVersion,omitempty"`
+
+	// Deprecated indicates the chart version has been marked as deprecated by its maintainers
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Deprecated"
+	Deprecated bool `json:"deprecated,omitempty"`
 }
 
 type HelmChartMaintainer struct {
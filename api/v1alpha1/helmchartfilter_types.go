@@ -0,0 +1,97 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HelmChartFilterSpec defines the desired state of HelmChartFilter
+type HelmChartFilterSpec struct {
+
+	// RepositorySelector selects the repositories the filter applies to using their labels. The filter applies to
+	// all repositories when absent
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Repository selector"
+	RepositorySelector *metav1.LabelSelector `json:"repositorySelector,omitempty"`
+
+	// Include represents the rules selecting the chart versions that are synchronized. All chart versions are
+	// included when no rules are specified
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Include rules"
+	Include []HelmChartFilterRule `json:"include,omitempty"`
+
+	// Exclude represents the rules selecting the chart versions that are not synchronized, even when they are included
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Exclude rules"
+	Exclude []HelmChartFilterRule `json:"exclude,omitempty"`
+}
+
+// HelmChartFilterRule matches chart versions. A chart version matches the rule when it matches all of the specified
+// fields
+type HelmChartFilterRule struct {
+
+	// Name represents a glob pattern matching the chart name, such as nginx-*
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name"
+	Name string `json:"name,omitempty"`
+
+	// Keyword represents a keyword the chart version must contain
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Keyword"
+	Keyword string `json:"keyword,omitempty"`
+
+	// Type represents the type of the chart. Charts without a type are application charts
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=application;library
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Type"
+	Type string `json:"type,omitempty"`
+
+	// Maintainer represents a glob pattern matching the name or email of a maintainer of the chart version
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Maintainer"
+	Maintainer string `json:"maintainer,omitempty"`
+
+	// Versions represents a semantic version range matching the chart version, such as >=1.2.0 <2.0.0
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Versions"
+	Versions string `json:"versions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+// +kubebuilder:resource:path=helmchartfilters,scope=Cluster
+
+// HelmChartFilter is the Schema for the helmchartfilters API. It selects the charts synchronized from repositories
+type HelmChartFilter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HelmChartFilterSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HelmChartFilterList contains a list of HelmChartFilter
+type HelmChartFilterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HelmChartFilter `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HelmChartFilter{}, &HelmChartFilterList{})
+}
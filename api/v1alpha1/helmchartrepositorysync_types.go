@@ -0,0 +1,191 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HelmChartRepositorySyncSpec defines the desired state of HelmChartRepositorySync
+type HelmChartRepositorySyncSpec struct {
+
+	// RepositoryName represents the name of the repository
+	// +kubebuilder:validation:Required
+	// +operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Repository name"
+	RepositoryName string `json:"repositoryName"`
+}
+
+// HelmChartRepositorySyncStatus defines the observed state of HelmChartRepositorySync
+type HelmChartRepositorySyncStatus struct {
+
+	// Conditions represents the observed conditions of the synchronization
+	// +kubebuilder:validation:Optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// LastSyncTimestamp represents the time the repository was last synchronized
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last Sync Time"
+	LastSyncTimestamp *metav1.Time `json:"lastSyncTimestamp,omitempty"`
+
+	// LastSuccessfulSyncTimestamp represents the time the repository was last synchronized successfully
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last Successful Sync Time"
+	LastSuccessfulSyncTimestamp *metav1.Time `json:"lastSuccessfulSyncTimestamp,omitempty"`
+
+	// IndexGeneratedTimestamp represents the time the repository index was generated
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Index Generation Time"
+	IndexGeneratedTimestamp *metav1.Time `json:"indexGeneratedTimestamp,omitempty"`
+
+	// ChartCount represents the number of charts synchronized from the repository
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Chart count"
+	ChartCount int `json:"chartCount,omitempty"`
+
+	// VersionCount represents the number of chart versions synchronized from the repository
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Version count"
+	VersionCount int `json:"versionCount,omitempty"`
+
+	// LastError represents the error encountered during the most recent synchronization
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last error"
+	LastError string `json:"lastError,omitempty"`
+
+	// LastHandledRefreshRequest represents the value of the most recent refresh request annotation that has been handled
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Last handled refresh request"
+	LastHandledRefreshRequest string `json:"lastHandledRefreshRequest,omitempty"`
+
+	// FailedCharts represents the charts that could not be synchronized
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Failed charts"
+	FailedCharts []HelmChartSyncFailure `json:"failedCharts,omitempty"`
+
+	// OmittedFailedCharts represents the number of charts that could not be synchronized and are not listed in FailedCharts
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Omitted failed charts"
+	OmittedFailedCharts int `json:"omittedFailedCharts,omitempty"`
+}
+
+type HelmChartSyncFailure struct {
+
+	// Name represents the name of the chart
+	// +kubebuilder:validation:Required
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Chart name"
+	Name string `json:"name"`
+
+	// Message represents the reason the chart could not be synchronized
+	// +kubebuilder:validation:Optional
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Failure message"
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// HelmChartRepositorySyncReachable indicates whether the repository could be contacted
+	HelmChartRepositorySyncReachable = "Reachable"
+
+	// HelmChartRepositorySyncIndexParsed indicates whether the repository index could be parsed
+	HelmChartRepositorySyncIndexParsed = "IndexParsed"
+
+	// HelmChartRepositorySyncSynced indicates whether the charts of the repository have been synchronized
+	HelmChartRepositorySyncSynced = "Synced"
+
+	// HelmChartRepositorySyncConfigurationValid indicates whether the annotations of the repository are valid
+	HelmChartRepositorySyncConfigurationValid = "ConfigurationValid"
+
+	// HelmChartRepositorySyncValidAnnotationsReason is the reason used when the annotations of the repository are valid
+	HelmChartRepositorySyncValidAnnotationsReason = "ValidAnnotations"
+
+	// HelmChartRepositorySyncInvalidAnnotationsReason is the reason used when annotations of the repository are invalid
+	HelmChartRepositorySyncInvalidAnnotationsReason = "InvalidAnnotations"
+
+	// HelmChartRepositorySyncIndexRetrievedReason is the reason used when the repository index was retrieved
+	HelmChartRepositorySyncIndexRetrievedReason = "IndexRetrieved"
+
+	// HelmChartRepositorySyncConfigurationInvalidReason is the reason used when the repository connection could not be configured
+	HelmChartRepositorySyncConfigurationInvalidReason = "ConfigurationInvalid"
+
+	// HelmChartRepositorySyncFetchFailedReason is the reason used when the repository index could not be retrieved
+	HelmChartRepositorySyncFetchFailedReason = "FetchFailed"
+
+	// HelmChartRepositorySyncIndexValidReason is the reason used when the repository index was parsed
+	HelmChartRepositorySyncIndexValidReason = "IndexValid"
+
+	// HelmChartRepositorySyncIndexInvalidReason is the reason used when the repository index could not be parsed
+	HelmChartRepositorySyncIndexInvalidReason = "IndexInvalid"
+
+	// HelmChartRepositorySyncSucceededReason is the reason used when the charts of the repository were synchronized
+	HelmChartRepositorySyncSucceededReason = "SyncSucceeded"
+
+	// HelmChartRepositorySyncFailedReason is the reason used when the charts of the repository could not be synchronized
+	HelmChartRepositorySyncFailedReason = "SyncFailed"
+
+	// HelmChartRepositorySyncChartsFailedReason is the reason used when individual charts of the repository could not be synchronized
+	HelmChartRepositorySyncChartsFailedReason = "ChartsFailed"
+
+	// HelmChartRepositorySyncDisabledReason is the reason used when the repository is disabled
+	HelmChartRepositorySyncDisabledReason = "RepositoryDisabled"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Repository",type=string,JSONPath=".spec.repositoryName",description="Chart Repository"
+// +kubebuilder:printcolumn:name="Synced",type=string,JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="Synchronization Status"
+// +kubebuilder:printcolumn:name="Charts",type=integer,JSONPath=".status.chartCount",description="Number of Charts"
+// +kubebuilder:printcolumn:name="Versions",type=integer,JSONPath=".status.versionCount",description="Number of Chart Versions"
+// +kubebuilder:printcolumn:name="Last Successful Sync",type=date,JSONPath=".status.lastSuccessfulSyncTimestamp",description="Last Successful Synchronization"
+// +kubebuilder:resource:path=helmchartrepositorysyncs,scope=Cluster
+
+// HelmChartRepositorySync is the Schema for the helmchartrepositorysyncs API
+type HelmChartRepositorySync struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HelmChartRepositorySyncSpec   `json:"spec,omitempty"`
+	Status HelmChartRepositorySyncStatus `json:"status,omitempty"`
+}
+
+func (h *HelmChartRepositorySync) GetConditions() []metav1.Condition {
+	return h.Status.Conditions
+}
+
+func (h *HelmChartRepositorySync) SetConditions(conditions []metav1.Condition) {
+	h.Status.Conditions = conditions
+}
+
+func (h *HelmChartRepositorySync) GetSyncStatus() *HelmChartRepositorySyncStatus {
+	return &h.Status
+}
+
+//+kubebuilder:object:root=true
+
+// HelmChartRepositorySyncList contains a list of HelmChartRepositorySync
+type HelmChartRepositorySyncList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HelmChartRepositorySync `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HelmChartRepositorySync{}, &HelmChartRepositorySyncList{})
+}
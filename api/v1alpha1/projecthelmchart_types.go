@@ -0,0 +1,68 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (h *ProjectHelmChart) GetConditions() []metav1.Condition {
+	return h.Status.Conditions
+}
+
+func (h *ProjectHelmChart) SetConditions(conditions []metav1.Condition) {
+	h.Status.Conditions = conditions
+}
+
+func (h *ProjectHelmChart) GetHelmChartSpec() *HelmChartSpec {
+	return &h.Spec
+}
+
+func (h *ProjectHelmChart) GetHelmChartStatus() *HelmChartStatus {
+	return &h.Status
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Repository",type=string,JSONPath=".spec.repositoryName",description="Chart Repository"
+// +kubebuilder:printcolumn:name="Name",type=string,JSONPath=".spec.name",description="Chart Name"
+// +kubebuilder:printcolumn:name="Latest Version",type=string,JSONPath=".status.latestVersion",description="Latest Chart Version"
+// +kubebuilder:printcolumn:name="Latest Stable",type=string,JSONPath=".status.latestStableVersion",description="Latest Stable Chart Version"
+// +kubebuilder:printcolumn:name="App Version",type=string,JSONPath=".status.latestAppVersion",description="Application Version of the Latest Chart Version"
+// +kubebuilder:printcolumn:name="Versions",type=integer,JSONPath=".status.versionCount",description="Number of Chart Versions"
+// +kubebuilder:resource:path=projecthelmcharts,scope=Namespaced
+
+// ProjectHelmChart is the Schema for the projecthelmcharts API. It represents a chart of a project scoped repository
+type ProjectHelmChart struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HelmChartSpec   `json:"spec,omitempty"`
+	Status HelmChartStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProjectHelmChartList contains a list of ProjectHelmChart
+type ProjectHelmChartList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProjectHelmChart `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProjectHelmChart{}, &ProjectHelmChartList{})
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Repository",type=string,JSONPath=".spec.repositoryName",description="Chart Repository"
+// +kubebuilder:printcolumn:name="Synced",type=string,JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="Synchronization Status"
+// +kubebuilder:printcolumn:name="Charts",type=integer,JSONPath=".status.chartCount",description="Number of Charts"
+// +kubebuilder:printcolumn:name="Versions",type=integer,JSONPath=".status.versionCount",description="Number of Chart Versions"
+// +kubebuilder:printcolumn:name="Last Successful Sync",type=date,JSONPath=".status.lastSuccessfulSyncTimestamp",description="Last Successful Synchronization"
+// +kubebuilder:resource:path=projecthelmchartrepositorysyncs,scope=Namespaced
+
+// ProjectHelmChartRepositorySync is the Schema for the projecthelmchartrepositorysyncs API. It represents the
+// synchronization status of a project scoped repository
+type ProjectHelmChartRepositorySync struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HelmChartRepositorySyncSpec   `json:"spec,omitempty"`
+	Status HelmChartRepositorySyncStatus `json:"status,omitempty"`
+}
+
+func (h *ProjectHelmChartRepositorySync) GetConditions() []metav1.Condition {
+	return h.Status.Conditions
+}
+
+func (h *ProjectHelmChartRepositorySync) SetConditions(conditions []metav1.Condition) {
+	h.Status.Conditions = conditions
+}
+
+func (h *ProjectHelmChartRepositorySync) GetSyncStatus() *HelmChartRepositorySyncStatus {
+	return &h.Status
+}
+
+//+kubebuilder:object:root=true
+
+// ProjectHelmChartRepositorySyncList contains a list of ProjectHelmChartRepositorySync
+type ProjectHelmChartRepositorySyncList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProjectHelmChartRepositorySync `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProjectHelmChartRepositorySync{}, &ProjectHelmChartRepositorySyncList{})
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (h *ProjectHelmChartVersion) GetHelmChartVersionSpec() *HelmChartVersionSpec {
+	return &h.Spec
+}
+
+//+kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Repository",type=string,JSONPath=".spec.repositoryName",description="Chart Repository"
+// +kubebuilder:printcolumn:name="Chart",type=string,JSONPath=".spec.chartName",description="Chart Name"
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=".spec.version",description="Chart Version"
+// +kubebuilder:printcolumn:name="App Version",type=string,JSONPath=".spec.appVersion",description="Application Version"
+// +kubebuilder:printcolumn:name="Created",type=date,JSONPath=".spec.created",description="Chart Creation Time"
+// +kubebuilder:printcolumn:name="Deprecated",type=boolean,JSONPath=".spec.deprecated",description="Deprecated Chart Version",priority=1
+// +kubebuilder:resource:path=projecthelmchartversions,scope=Namespaced
+
+// ProjectHelmChartVersion is the Schema for the projecthelmchartversions API. It represents a version of a
+// ProjectHelmChart
+type ProjectHelmChartVersion struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HelmChartVersionSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProjectHelmChartVersionList contains a list of ProjectHelmChartVersion
+type ProjectHelmChartVersionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProjectHelmChartVersion `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProjectHelmChartVersion{}, &ProjectHelmChartVersionList{})
+}
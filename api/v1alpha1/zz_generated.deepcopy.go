@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -71,6 +72,109 @@ func (in *HelmChartDependency) DeepCopy() *HelmChartDependency {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartFilter) DeepCopyInto(out *HelmChartFilter) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartFilter.
+func (in *HelmChartFilter) DeepCopy() *HelmChartFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HelmChartFilter) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartFilterList) DeepCopyInto(out *HelmChartFilterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HelmChartFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartFilterList.
+func (in *HelmChartFilterList) DeepCopy() *HelmChartFilterList {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartFilterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HelmChartFilterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartFilterRule) DeepCopyInto(out *HelmChartFilterRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartFilterRule.
+func (in *HelmChartFilterRule) DeepCopy() *HelmChartFilterRule {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartFilterRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartFilterSpec) DeepCopyInto(out *HelmChartFilterSpec) {
+	*out = *in
+	if in.RepositorySelector != nil {
+		in, out := &in.RepositorySelector, &out.RepositorySelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]HelmChartFilterRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]HelmChartFilterRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartFilterSpec.
+func (in *HelmChartFilterSpec) DeepCopy() *HelmChartFilterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartFilterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmChartList) DeepCopyInto(out *HelmChartList) {
 	*out = *in
@@ -118,12 +222,125 @@ func (in *HelmChartMaintainer) DeepCopy() *HelmChartMaintainer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartRepositorySync) DeepCopyInto(out *HelmChartRepositorySync) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartRepositorySync.
+func (in *HelmChartRepositorySync) DeepCopy() *HelmChartRepositorySync {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartRepositorySync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HelmChartRepositorySync) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartRepositorySyncList) DeepCopyInto(out *HelmChartRepositorySyncList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HelmChartRepositorySync, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartRepositorySyncList.
+func (in *HelmChartRepositorySyncList) DeepCopy() *HelmChartRepositorySyncList {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartRepositorySyncList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HelmChartRepositorySyncList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartRepositorySyncSpec) DeepCopyInto(out *HelmChartRepositorySyncSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartRepositorySyncSpec.
+func (in *HelmChartRepositorySyncSpec) DeepCopy() *HelmChartRepositorySyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartRepositorySyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartRepositorySyncStatus) DeepCopyInto(out *HelmChartRepositorySyncStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTimestamp != nil {
+		in, out := &in.LastSyncTimestamp, &out.LastSyncTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulSyncTimestamp != nil {
+		in, out := &in.LastSuccessfulSyncTimestamp, &out.LastSuccessfulSyncTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.IndexGeneratedTimestamp != nil {
+		in, out := &in.IndexGeneratedTimestamp, &out.IndexGeneratedTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.FailedCharts != nil {
+		in, out := &in.FailedCharts, &out.FailedCharts
+		*out = make([]HelmChartSyncFailure, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartRepositorySyncStatus.
+func (in *HelmChartRepositorySyncStatus) DeepCopy() *HelmChartRepositorySyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartRepositorySyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmChartSpec) DeepCopyInto(out *HelmChartSpec) {
 	*out = *in
 	if in.Versions != nil {
 		in, out := &in.Versions, &out.Versions
-		*out = make([]HelmChartVersion, len(*in))
+		*out = make([]HelmChartVersionSpec, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -147,6 +364,21 @@ func (in *HelmChartStatus) DeepCopyInto(out *HelmChartStatus) {
 		in, out := &in.LastUpdateTimestamp, &out.LastUpdateTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.LastCheckedTimestamp != nil {
+		in, out := &in.LastCheckedTimestamp, &out.LastCheckedTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.LatestCreated != nil {
+		in, out := &in.LatestCreated, &out.LatestCreated
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartStatus.
@@ -159,8 +391,81 @@ func (in *HelmChartStatus) DeepCopy() *HelmChartStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartSyncFailure) DeepCopyInto(out *HelmChartSyncFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartSyncFailure.
+func (in *HelmChartSyncFailure) DeepCopy() *HelmChartSyncFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartSyncFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmChartVersion) DeepCopyInto(out *HelmChartVersion) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartVersion.
+func (in *HelmChartVersion) DeepCopy() *HelmChartVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HelmChartVersion) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartVersionList) DeepCopyInto(out *HelmChartVersionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HelmChartVersion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartVersionList.
+func (in *HelmChartVersionList) DeepCopy() *HelmChartVersionList {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmChartVersionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HelmChartVersionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmChartVersionSpec) DeepCopyInto(out *HelmChartVersionSpec) {
 	*out = *in
 	if in.Created != nil {
 		in, out := &in.Created, &out.Created
@@ -207,12 +512,188 @@ func (in *HelmChartVersion) DeepCopyInto(out *HelmChartVersion) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartVersion.
-func (in *HelmChartVersion) DeepCopy() *HelmChartVersion {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartVersionSpec.
+func (in *HelmChartVersionSpec) DeepCopy() *HelmChartVersionSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HelmChartVersion)
+	out := new(HelmChartVersionSpec)
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectHelmChart) DeepCopyInto(out *ProjectHelmChart) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectHelmChart.
+func (in *ProjectHelmChart) DeepCopy() *ProjectHelmChart {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectHelmChart)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProjectHelmChart) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectHelmChartList) DeepCopyInto(out *ProjectHelmChartList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProjectHelmChart, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectHelmChartList.
+func (in *ProjectHelmChartList) DeepCopy() *ProjectHelmChartList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectHelmChartList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProjectHelmChartList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectHelmChartRepositorySync) DeepCopyInto(out *ProjectHelmChartRepositorySync) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectHelmChartRepositorySync.
+func (in *ProjectHelmChartRepositorySync) DeepCopy() *ProjectHelmChartRepositorySync {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectHelmChartRepositorySync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProjectHelmChartRepositorySync) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectHelmChartRepositorySyncList) DeepCopyInto(out *ProjectHelmChartRepositorySyncList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProjectHelmChartRepositorySync, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectHelmChartRepositorySyncList.
+func (in *ProjectHelmChartRepositorySyncList) DeepCopy() *ProjectHelmChartRepositorySyncList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectHelmChartRepositorySyncList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProjectHelmChartRepositorySyncList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectHelmChartVersion) DeepCopyInto(out *ProjectHelmChartVersion) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectHelmChartVersion.
+func (in *ProjectHelmChartVersion) DeepCopy() *ProjectHelmChartVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectHelmChartVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProjectHelmChartVersion) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProjectHelmChartVersionList) DeepCopyInto(out *ProjectHelmChartVersionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProjectHelmChartVersion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProjectHelmChartVersionList.
+func (in *ProjectHelmChartVersionList) DeepCopy() *ProjectHelmChartVersionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProjectHelmChartVersionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProjectHelmChartVersionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/oci"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/types"
+)
+
+// minSyncInterval is the shortest period between synchronizations of a repository that can be configured, preventing
+// repositories from being polled continuously
+const minSyncInterval = time.Minute
+
+const (
+	annotationPrefix = "helm-chart-repository-operator.redhat-cop.io/"
+
+	// syncIntervalAnnotation overrides the reconcile period of the repository using a Go duration
+	syncIntervalAnnotation = annotationPrefix + "sync-interval"
+
+	// refreshRequestedAnnotation requests an immediate synchronization that bypasses the index cache whenever its value changes
+	refreshRequestedAnnotation = annotationPrefix + "refresh-requested"
+
+	// authSecretAnnotation references a Secret in the configuration namespace of the repository containing credentials
+	authSecretAnnotation = annotationPrefix + "auth-secret"
+
+	// passCredentialsAnnotation enables sending repository credentials to hosts other than the repository host, including
+	// the hosts of chart URLs, following the pass_credentials_all setting of Helm
+	passCredentialsAnnotation = annotationPrefix + "pass-credentials"
+
+	// sourceURLAnnotation overrides the URL of the repository, allowing locations such as oci:// registries, file://
+	// paths and configmap:// references that are not admitted by the HelmChartRepository schema
+	sourceURLAnnotation = annotationPrefix + "source-url"
+
+	// plainHTTPAnnotation accesses an OCI registry without TLS
+	plainHTTPAnnotation = annotationPrefix + "plain-http"
+
+	// retainMaxVersionsAnnotation limits the number of most recent versions synchronized for each chart. Zero disables
+	// the limit
+	retainMaxVersionsAnnotation = annotationPrefix + "retain-max-versions"
+
+	// retainMaxAgeAnnotation excludes versions created longer ago than the Go duration. Zero disables the limit
+	retainMaxAgeAnnotation = annotationPrefix + "retain-max-age"
+
+	// retainNewerThanAnnotation excludes versions created before the RFC 3339 timestamp
+	retainNewerThanAnnotation = annotationPrefix + "retain-newer-than"
+
+	// retainLatestPatchAnnotation synchronizes only the most recent patch version of each minor version
+	retainLatestPatchAnnotation = annotationPrefix + "retain-latest-patch"
+
+	// hidePrereleasesAnnotation omits versions with a semantic version prerelease, such as 1.0.0-rc.1
+	hidePrereleasesAnnotation = annotationPrefix + "hide-prereleases"
+
+	// hideDeprecatedVersionsAnnotation omits versions marked as deprecated
+	hideDeprecatedVersionsAnnotation = annotationPrefix + "hide-deprecated-versions"
+
+	// hideDeprecatedChartsAnnotation omits charts whose latest version is marked as deprecated
+	hideDeprecatedChartsAnnotation = annotationPrefix + "hide-deprecated-charts"
+)
+
+// versionVisibilityOptions represents the settings of a repository hiding prereleases and deprecated charts
+type versionVisibilityOptions struct {
+	HidePrereleases        bool
+	HideDeprecatedVersions bool
+	HideDeprecatedCharts   bool
+}
+
+// versionRetentionOptions represents the version retention settings of a repository
+type versionRetentionOptions struct {
+	MaxVersions     int
+	MaxAge          time.Duration
+	NewerThan       time.Time
+	LatestPatchOnly bool
+}
+
+// versionRetention resolves the retention settings at the given time. The most recent of the maximum age and the
+// timestamp is used when both are set
+func (o versionRetentionOptions) versionRetention(now time.Time) *types.VersionRetention {
+
+	retention := &types.VersionRetention{
+		MaxVersions:     o.MaxVersions,
+		NewerThan:       o.NewerThan,
+		LatestPatchOnly: o.LatestPatchOnly,
+	}
+
+	if o.MaxAge > 0 {
+		if maxAgeCutoff := now.Add(-o.MaxAge); maxAgeCutoff.After(retention.NewerThan) {
+			retention.NewerThan = maxAgeCutoff
+		}
+	}
+
+	return retention
+}
+
+// repositoryOptions represents the settings of a repository that can be configured using annotations
+type repositoryOptions struct {
+	SyncInterval     time.Duration
+	RefreshRequested string
+	AuthSecret       string
+	PassCredentials  bool
+	URL              string
+	PlainHTTP        bool
+	Retention        versionRetentionOptions
+	Visibility       versionVisibilityOptions
+}
+
+// getRepositoryOptions returns the settings of the repository. Operator defaults are used for annotations that are
+// absent or invalid and an error is returned for each invalid annotation
+func (r *RepositoryReconciler) getRepositoryOptions(repository *chartRepository) (*repositoryOptions, []error) {
+
+	options := &repositoryOptions{
+		SyncInterval: time.Second * time.Duration(r.ReconcilePeriod),
+		URL:          repository.url,
+		Retention: versionRetentionOptions{
+			MaxVersions:     r.RetainMaxVersions,
+			MaxAge:          time.Hour * 24 * time.Duration(r.RetainMaxAgeDays),
+			LatestPatchOnly: r.RetainLatestPatch,
+		},
+	}
+
+	errs := []error{}
+	annotations := repository.object.GetAnnotations()
+
+	if syncInterval, found := annotations[syncIntervalAnnotation]; found {
+		syncIntervalDuration, err := time.ParseDuration(syncInterval)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", syncInterval, syncIntervalAnnotation, err))
+		} else if syncIntervalDuration < minSyncInterval {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: must be at least %s", syncInterval, syncIntervalAnnotation, minSyncInterval))
+		} else {
+			options.SyncInterval = syncIntervalDuration
+		}
+	}
+
+	options.RefreshRequested = annotations[refreshRequestedAnnotation]
+	options.AuthSecret = annotations[authSecretAnnotation]
+
+	if passCredentials, found := annotations[passCredentialsAnnotation]; found {
+		passCredentialsBool, err := strconv.ParseBool(passCredentials)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", passCredentials, passCredentialsAnnotation, err))
+		} else {
+			options.PassCredentials = passCredentialsBool
+		}
+	}
+
+	if sourceURL, found := annotations[sourceURLAnnotation]; found {
+		sourceURLParsed, err := url.Parse(sourceURL)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", sourceURL, sourceURLAnnotation, err))
+		} else if !isSupportedURL(sourceURLParsed) {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: unsupported repository URL", sourceURL, sourceURLAnnotation))
+		} else if sourceURLParsed.Scheme == fileScheme && !repository.allowLocalFiles {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: local index files are not permitted for this repository", sourceURL, sourceURLAnnotation))
+		} else {
+			options.URL = sourceURL
+		}
+	}
+
+	if plainHTTP, found := annotations[plainHTTPAnnotation]; found {
+		plainHTTPBool, err := strconv.ParseBool(plainHTTP)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", plainHTTP, plainHTTPAnnotation, err))
+		} else {
+			options.PlainHTTP = plainHTTPBool
+		}
+	}
+
+	if maxVersions, found := annotations[retainMaxVersionsAnnotation]; found {
+		maxVersionsInt, err := strconv.Atoi(maxVersions)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", maxVersions, retainMaxVersionsAnnotation, err))
+		} else if maxVersionsInt < 0 {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: must not be negative", maxVersions, retainMaxVersionsAnnotation))
+		} else {
+			options.Retention.MaxVersions = maxVersionsInt
+		}
+	}
+
+	if maxAge, found := annotations[retainMaxAgeAnnotation]; found {
+		maxAgeDuration, err := time.ParseDuration(maxAge)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", maxAge, retainMaxAgeAnnotation, err))
+		} else if maxAgeDuration < 0 {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: must not be negative", maxAge, retainMaxAgeAnnotation))
+		} else {
+			options.Retention.MaxAge = maxAgeDuration
+		}
+	}
+
+	if newerThan, found := annotations[retainNewerThanAnnotation]; found {
+		newerThanTime, err := time.Parse(time.RFC3339, newerThan)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", newerThan, retainNewerThanAnnotation, err))
+		} else {
+			options.Retention.NewerThan = newerThanTime.UTC()
+		}
+	}
+
+	if latestPatch, found := annotations[retainLatestPatchAnnotation]; found {
+		latestPatchBool, err := strconv.ParseBool(latestPatch)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", latestPatch, retainLatestPatchAnnotation, err))
+		} else {
+			options.Retention.LatestPatchOnly = latestPatchBool
+		}
+	}
+
+	// The annotations are parsed in a fixed order so the reported errors do not change between reconciles
+	for _, visibilityAnnotation := range []struct {
+		annotation string
+		value      *bool
+	}{
+		{annotation: hidePrereleasesAnnotation, value: &options.Visibility.HidePrereleases},
+		{annotation: hideDeprecatedVersionsAnnotation, value: &options.Visibility.HideDeprecatedVersions},
+		{annotation: hideDeprecatedChartsAnnotation, value: &options.Visibility.HideDeprecatedCharts},
+	} {
+		if hide, found := annotations[visibilityAnnotation.annotation]; found {
+			hideBool, err := strconv.ParseBool(hide)
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("Invalid value %q for annotation %s: %v", hide, visibilityAnnotation.annotation, err))
+			} else {
+				*visibilityAnnotation.value = hideBool
+			}
+		}
+	}
+
+	return options, errs
+}
+
+// isSupportedURL determines whether repositories can be synchronized from the URL
+func isSupportedURL(sourceURL *url.URL) bool {
+
+	switch sourceURL.Scheme {
+	case "http", "https", oci.Scheme, configMapScheme:
+		return sourceURL.Host != ""
+	case fileScheme:
+		return sourceURL.Host == "" && filepath.IsAbs(sourceURL.Path)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// repositoryOptionsTest describes the expected settings of a repository with the given annotations. The expected
+// settings are applied to the operator defaults and the expected errors are matched in order
+type repositoryOptionsTest struct {
+	name            string
+	annotations     map[string]string
+	allowLocalFiles bool
+	expected        func(options *repositoryOptions)
+	expectedErrors  []string
+}
+
+func runRepositoryOptionsTests(t *testing.T, tests []repositoryOptionsTest) {
+
+	r := &RepositoryReconciler{ReconcilePeriod: 300}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			repository := &chartRepository{
+				object:          &helmv1beta1.HelmChartRepository{ObjectMeta: metav1.ObjectMeta{Name: "repository", Annotations: test.annotations}},
+				url:             "https://charts.example.com",
+				allowLocalFiles: test.allowLocalFiles,
+			}
+
+			expectedOptions := &repositoryOptions{SyncInterval: 300 * time.Second, URL: "https://charts.example.com"}
+			if test.expected != nil {
+				test.expected(expectedOptions)
+			}
+
+			options, errs := r.getRepositoryOptions(repository)
+
+			if !reflect.DeepEqual(options, expectedOptions) {
+				t.Errorf("expected options %+v, got %+v", expectedOptions, options)
+			}
+
+			if len(errs) != len(test.expectedErrors) {
+				t.Fatalf("expected %d errors, got %v", len(test.expectedErrors), errs)
+			}
+
+			for i, err := range errs {
+				if !strings.Contains(err.Error(), test.expectedErrors[i]) {
+					t.Errorf("expected error %d to contain %q, got %q", i, test.expectedErrors[i], err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestGetRepositoryOptionsVisibility(t *testing.T) {
+
+	runRepositoryOptionsTests(t, []repositoryOptionsTest{
+		{
+			name: "hidden prereleases and deprecated charts",
+			annotations: map[string]string{
+				hidePrereleasesAnnotation:        "true",
+				hideDeprecatedVersionsAnnotation: "false",
+				hideDeprecatedChartsAnnotation:   "true",
+			},
+			expected: func(options *repositoryOptions) {
+				options.Visibility = versionVisibilityOptions{HidePrereleases: true, HideDeprecatedCharts: true}
+			},
+		},
+		{
+			name: "invalid values are reported in a fixed order",
+			annotations: map[string]string{
+				hideDeprecatedChartsAnnotation:   "sometimes",
+				hideDeprecatedVersionsAnnotation: "never",
+				hidePrereleasesAnnotation:        "maybe",
+			},
+			expectedErrors: []string{hidePrereleasesAnnotation, hideDeprecatedVersionsAnnotation, hideDeprecatedChartsAnnotation},
+		},
+	})
+}
+
+func TestGetRepositoryOptionsSyncInterval(t *testing.T) {
+
+	runRepositoryOptionsTests(t, []repositoryOptionsTest{
+		{
+			name: "defaults",
+		},
+		{
+			name:        "sync interval",
+			annotations: map[string]string{syncIntervalAnnotation: "5m"},
+			expected: func(options *repositoryOptions) {
+				options.SyncInterval = 5 * time.Minute
+			},
+		},
+		{
+			name:        "minimum sync interval",
+			annotations: map[string]string{syncIntervalAnnotation: "1m"},
+			expected: func(options *repositoryOptions) {
+				options.SyncInterval = time.Minute
+			},
+		},
+		{
+			name:           "sync interval below minimum",
+			annotations:    map[string]string{syncIntervalAnnotation: "30s"},
+			expectedErrors: []string{"must be at least 1m0s"},
+		},
+		{
+			name:           "invalid sync interval",
+			annotations:    map[string]string{syncIntervalAnnotation: "hourly"},
+			expectedErrors: []string{syncIntervalAnnotation},
+		},
+	})
+}
+
+func TestGetRepositoryOptionsCredentials(t *testing.T) {
+
+	runRepositoryOptionsTests(t, []repositoryOptionsTest{
+		{
+			name: "credentials",
+			annotations: map[string]string{
+				authSecretAnnotation:      "repository-credentials",
+				passCredentialsAnnotation: "true",
+			},
+			expected: func(options *repositoryOptions) {
+				options.AuthSecret = "repository-credentials"
+				options.PassCredentials = true
+			},
+		},
+		{
+			name:           "invalid pass credentials",
+			annotations:    map[string]string{passCredentialsAnnotation: "always"},
+			expectedErrors: []string{passCredentialsAnnotation},
+		},
+	})
+}
+
+func TestGetRepositoryOptionsSourceURL(t *testing.T) {
+
+	runRepositoryOptionsTests(t, []repositoryOptionsTest{
+		{
+			name: "OCI registry",
+			annotations: map[string]string{
+				sourceURLAnnotation: "oci://registry.example.com/charts",
+				plainHTTPAnnotation: "true",
+			},
+			expected: func(options *repositoryOptions) {
+				options.URL = "oci://registry.example.com/charts"
+				options.PlainHTTP = true
+			},
+		},
+		{
+			name:        "HTTP repository",
+			annotations: map[string]string{sourceURLAnnotation: "http://mirror.example.com/charts"},
+			expected: func(options *repositoryOptions) {
+				options.URL = "http://mirror.example.com/charts"
+			},
+		},
+		{
+			name:           "unsupported scheme",
+			annotations:    map[string]string{sourceURLAnnotation: "ftp://charts.example.com"},
+			expectedErrors: []string{"unsupported repository URL"},
+		},
+		{
+			name:           "missing host",
+			annotations:    map[string]string{sourceURLAnnotation: "oci:///charts"},
+			expectedErrors: []string{"unsupported repository URL"},
+		},
+		{
+			name:           "invalid plain HTTP",
+			annotations:    map[string]string{plainHTTPAnnotation: "insecure"},
+			expectedErrors: []string{plainHTTPAnnotation},
+		},
+	})
+}
+
+func TestGetRepositoryOptionsLocalSourceURL(t *testing.T) {
+
+	runRepositoryOptionsTests(t, []repositoryOptionsTest{
+		{
+			name:            "local index file",
+			annotations:     map[string]string{sourceURLAnnotation: "file:///var/lib/charts/index.yaml"},
+			allowLocalFiles: true,
+			expected: func(options *repositoryOptions) {
+				options.URL = "file:///var/lib/charts/index.yaml"
+			},
+		},
+		{
+			name:           "local index file not permitted",
+			annotations:    map[string]string{sourceURLAnnotation: "file:///var/lib/charts/index.yaml"},
+			expectedErrors: []string{"local index files are not permitted"},
+		},
+		{
+			name:            "relative local index file",
+			annotations:     map[string]string{sourceURLAnnotation: "file://charts/index.yaml"},
+			allowLocalFiles: true,
+			expectedErrors:  []string{"unsupported repository URL"},
+		},
+		{
+			name:        "ConfigMap index",
+			annotations: map[string]string{sourceURLAnnotation: "configmap://chart-index/charts.yaml"},
+			expected: func(options *repositoryOptions) {
+				options.URL = "configmap://chart-index/charts.yaml"
+			},
+		},
+	})
+}
+
+func TestGetRepositoryOptionsRetention(t *testing.T) {
+
+	runRepositoryOptionsTests(t, []repositoryOptionsTest{
+		{
+			name: "retention settings",
+			annotations: map[string]string{
+				retainMaxVersionsAnnotation: "5",
+				retainMaxAgeAnnotation:      "720h",
+				retainNewerThanAnnotation:   "2021-06-01T12:00:00+02:00",
+				retainLatestPatchAnnotation: "true",
+			},
+			expected: func(options *repositoryOptions) {
+				options.Retention = versionRetentionOptions{
+					MaxVersions:     5,
+					MaxAge:          720 * time.Hour,
+					NewerThan:       time.Date(2021, time.June, 1, 10, 0, 0, 0, time.UTC),
+					LatestPatchOnly: true,
+				}
+			},
+		},
+		{
+			name: "limits disabled",
+			annotations: map[string]string{
+				retainMaxVersionsAnnotation: "0",
+				retainMaxAgeAnnotation:      "0s",
+			},
+		},
+		{
+			name: "negative values",
+			annotations: map[string]string{
+				retainMaxVersionsAnnotation: "-1",
+				retainMaxAgeAnnotation:      "-24h",
+			},
+			expectedErrors: []string{
+				retainMaxVersionsAnnotation + ": must not be negative",
+				retainMaxAgeAnnotation + ": must not be negative",
+			},
+		},
+		{
+			name: "invalid values",
+			annotations: map[string]string{
+				retainMaxVersionsAnnotation: "five",
+				retainMaxAgeAnnotation:      "30d",
+				retainNewerThanAnnotation:   "2021-06-01",
+				retainLatestPatchAnnotation: "yes",
+			},
+			expectedErrors: []string{
+				retainMaxVersionsAnnotation,
+				retainMaxAgeAnnotation,
+				retainNewerThanAnnotation,
+				retainLatestPatchAnnotation,
+			},
+		},
+	})
+}
+
+func TestVersionRetention(t *testing.T) {
+
+	now := time.Date(2021, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		options  versionRetentionOptions
+		expected time.Time
+	}{
+		{name: "no limits", options: versionRetentionOptions{}},
+		{name: "maximum age", options: versionRetentionOptions{MaxAge: 24 * time.Hour}, expected: now.Add(-24 * time.Hour)},
+		{name: "timestamp", options: versionRetentionOptions{NewerThan: now.Add(-48 * time.Hour)}, expected: now.Add(-48 * time.Hour)},
+		{name: "maximum age more recent than timestamp", options: versionRetentionOptions{MaxAge: 24 * time.Hour, NewerThan: now.Add(-48 * time.Hour)}, expected: now.Add(-24 * time.Hour)},
+		{name: "timestamp more recent than maximum age", options: versionRetentionOptions{MaxAge: 72 * time.Hour, NewerThan: now.Add(-48 * time.Hour)}, expected: now.Add(-48 * time.Hour)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			retention := test.options.versionRetention(now)
+
+			if !retention.NewerThan.Equal(test.expected) {
+				t.Errorf("expected versions newer than %s, got %s", test.expected, retention.NewerThan)
+			}
+		})
+	}
+}
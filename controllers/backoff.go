@@ -0,0 +1,115 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// jitterFactor is the maximum fraction by which requeue delays are extended
+	jitterFactor = 0.1
+)
+
+// repositoryBackoff tracks consecutive synchronization failures keyed by repository name
+type repositoryBackoff struct {
+	mutex    sync.Mutex
+	min      time.Duration
+	max      time.Duration
+	failures map[string]int
+}
+
+func newRepositoryBackoff(min time.Duration, max time.Duration) *repositoryBackoff {
+	if max < min {
+		max = min
+	}
+
+	return &repositoryBackoff{
+		min:      min,
+		max:      max,
+		failures: map[string]int{},
+	}
+}
+
+// Next records a failure of the repository and returns the jittered delay before it should be retried.
+// The delay doubles with each consecutive failure starting from min until it reaches max
+func (b *repositoryBackoff) Next(name string) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	failures := b.failures[name]
+	b.failures[name] = failures + 1
+
+	delay := b.min
+	for i := 0; i < failures && delay < b.max; i++ {
+		delay *= 2
+	}
+
+	if delay > b.max {
+		delay = b.max
+	}
+
+	return wait.Jitter(delay, jitterFactor)
+}
+
+// Reset clears the failures recorded for the repository
+func (b *repositoryBackoff) Reset(name string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.failures, name)
+}
+
+// syncSchedule determines the delay before the periodic synchronization of repositories. Repositories are reconciled
+// at the same time after the operator starts, so the first synchronization of each repository is spread across the
+// whole sync interval, while later synchronizations only apply jitter to retain the spread
+type syncSchedule struct {
+	mutex     sync.Mutex
+	scheduled map[string]struct{}
+}
+
+func newSyncSchedule() *syncSchedule {
+	return &syncSchedule{
+		scheduled: map[string]struct{}{},
+	}
+}
+
+// Next returns the delay before the next periodic synchronization of the repository
+func (s *syncSchedule) Next(name string, interval time.Duration) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, found := s.scheduled[name]; found || interval <= 0 {
+		return wait.Jitter(interval, jitterFactor)
+	}
+
+	s.scheduled[name] = struct{}{}
+
+	return time.Duration(rand.Int63n(int64(interval)))
+}
+
+// Reset spreads the next synchronization of the repository across the sync interval again
+func (s *syncSchedule) Reset(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.scheduled, name)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRepositoryBackoff(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		min      time.Duration
+		max      time.Duration
+		failures int
+		expected time.Duration
+	}{
+		{name: "first failure", min: 10 * time.Second, max: time.Minute, failures: 1, expected: 10 * time.Second},
+		{name: "consecutive failures", min: 10 * time.Second, max: time.Minute, failures: 3, expected: 40 * time.Second},
+		{name: "maximum delay", min: 10 * time.Second, max: time.Minute, failures: 10, expected: time.Minute},
+		{name: "maximum below minimum", min: 10 * time.Second, max: time.Second, failures: 2, expected: 10 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			backoff := newRepositoryBackoff(test.min, test.max)
+
+			var delay time.Duration
+			for i := 0; i < test.failures; i++ {
+				delay = backoff.Next("repository")
+			}
+
+			if maxDelay := test.expected + time.Duration(float64(test.expected)*jitterFactor); delay < test.expected || delay > maxDelay {
+				t.Errorf("expected a delay between %s and %s, got %s", test.expected, maxDelay, delay)
+			}
+
+			if delay := backoff.Next("other"); delay > test.min+time.Duration(float64(test.min)*jitterFactor) {
+				t.Errorf("expected failures of other repositories not to affect the delay, got %s", delay)
+			}
+
+			backoff.Reset("repository")
+
+			if delay := backoff.Next("repository"); delay > test.min+time.Duration(float64(test.min)*jitterFactor) {
+				t.Errorf("expected the delay to be reset, got %s", delay)
+			}
+		})
+	}
+}
+
+func TestSyncSchedule(t *testing.T) {
+
+	const interval = 10 * time.Minute
+	maxDelay := interval + time.Duration(float64(interval)*jitterFactor)
+
+	schedule := newSyncSchedule()
+
+	// The first delays are spread across the interval rather than all being close to it
+	spread := false
+	for i := 0; i < 50; i++ {
+		delay := schedule.Next(fmt.Sprintf("repository-%d", i), interval)
+
+		if delay < 0 || delay >= interval {
+			t.Fatalf("expected the first delay to be within the interval, got %s", delay)
+		}
+
+		if delay < interval/2 {
+			spread = true
+		}
+	}
+
+	if !spread {
+		t.Error("expected the first delays to be spread across the interval")
+	}
+
+	if delay := schedule.Next("repository-0", interval); delay < interval || delay > maxDelay {
+		t.Errorf("expected a delay between %s and %s, got %s", interval, maxDelay, delay)
+	}
+
+	schedule.Reset("repository-0")
+
+	if delay := schedule.Next("repository-0", interval); delay >= interval {
+		t.Errorf("expected the delay to be spread across the interval again, got %s", delay)
+	}
+}
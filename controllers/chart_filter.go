@@ -0,0 +1,69 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/filter"
+)
+
+// getChartFilter compiles the HelmChartFilters selecting the repository. Nil is returned when no filters apply to
+// the repository. A filter with an invalid repository selector fails the synchronization, as the repository may be
+// selected by the filter, and is reported using an event
+func (r *RepositoryReconciler) getChartFilter(ctx context.Context, repository *chartRepository) (*filter.ChartFilter, error) {
+
+	helmChartFilterList := &redhatcopv1alpha1.HelmChartFilterList{}
+	err := r.GetClient().List(ctx, helmChartFilterList)
+
+	if err != nil {
+		return nil, err
+	}
+
+	helmChartFilters, invalidFilter, err := selectChartFilters(helmChartFilterList.Items, repository.object.GetLabels())
+
+	if err != nil {
+		r.recordWarning(invalidFilter, FilterInvalidEventReason, err)
+		return nil, err
+	}
+
+	return filter.New(helmChartFilters)
+}
+
+// selectChartFilters returns the filters selecting a repository with the given labels. When the repository selector
+// of a filter is invalid, the filter is returned along with the error
+func selectChartFilters(helmChartFilters []redhatcopv1alpha1.HelmChartFilter, repositoryLabels map[string]string) ([]redhatcopv1alpha1.HelmChartFilter, *redhatcopv1alpha1.HelmChartFilter, error) {
+
+	selectedFilters := []redhatcopv1alpha1.HelmChartFilter{}
+
+	for i := range helmChartFilters {
+		selected, err := filter.Selects(&helmChartFilters[i], repositoryLabels)
+
+		// A filter with an invalid selector cannot determine whether it applies to the repository, so the
+		// synchronization fails rather than publishing charts the filter may exclude
+		if err != nil {
+			return nil, &helmChartFilters[i], err
+		}
+
+		if selected {
+			selectedFilters = append(selectedFilters, helmChartFilters[i])
+		}
+	}
+
+	return selectedFilters, nil, nil
+}
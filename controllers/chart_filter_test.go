@@ -0,0 +1,105 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newRepositoryChartFilter(name string, repositorySelector *metav1.LabelSelector) redhatcopv1alpha1.HelmChartFilter {
+	return redhatcopv1alpha1.HelmChartFilter{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: redhatcopv1alpha1.HelmChartFilterSpec{
+			RepositorySelector: repositorySelector,
+		},
+	}
+}
+
+func TestSelectChartFilters(t *testing.T) {
+
+	invalidSelector := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "catalog", Operator: "Matches"},
+	}}
+
+	tests := []struct {
+		name                  string
+		helmChartFilters      []redhatcopv1alpha1.HelmChartFilter
+		expectedFilters       []string
+		expectedInvalidFilter string
+	}{
+		{
+			name:            "no filters",
+			expectedFilters: []string{},
+		},
+		{
+			name: "selected filters",
+			helmChartFilters: []redhatcopv1alpha1.HelmChartFilter{
+				newRepositoryChartFilter("all", nil),
+				newRepositoryChartFilter("bitnami", &metav1.LabelSelector{MatchLabels: map[string]string{"catalog": "bitnami"}}),
+				newRepositoryChartFilter("curated", &metav1.LabelSelector{MatchLabels: map[string]string{"catalog": "curated"}}),
+			},
+			expectedFilters: []string{"all", "bitnami"},
+		},
+		{
+			name: "invalid selector",
+			helmChartFilters: []redhatcopv1alpha1.HelmChartFilter{
+				newRepositoryChartFilter("all", nil),
+				newRepositoryChartFilter("invalid", invalidSelector),
+			},
+			expectedInvalidFilter: "invalid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			helmChartFilters, invalidFilter, err := selectChartFilters(test.helmChartFilters, map[string]string{"catalog": "bitnami"})
+
+			if test.expectedInvalidFilter != "" {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				if invalidFilter == nil || invalidFilter.Name != test.expectedInvalidFilter {
+					t.Errorf("expected invalid filter %s, got %v", test.expectedInvalidFilter, invalidFilter)
+				}
+
+				if helmChartFilters != nil {
+					t.Errorf("expected no filters, got %v", helmChartFilters)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			filterNames := []string{}
+			for _, helmChartFilter := range helmChartFilters {
+				filterNames = append(filterNames, helmChartFilter.Name)
+			}
+
+			if !reflect.DeepEqual(filterNames, test.expectedFilters) {
+				t.Errorf("expected filters %v, got %v", test.expectedFilters, filterNames)
+			}
+		})
+	}
+}
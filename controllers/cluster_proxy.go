@@ -0,0 +1,157 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	configv1 "github.com/openshift/api/config/v1"
+	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
+	"golang.org/x/net/http/httpproxy"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// clusterProxyName is the name of the cluster-wide Proxy configuration
+	clusterProxyName = "cluster"
+
+	caBundleKey = "ca-bundle.crt"
+)
+
+var proxyGVK = configv1.GroupVersion.WithKind("Proxy")
+
+// clusterProxy represents the cluster-wide Proxy configuration used to access repositories
+type clusterProxy struct {
+	// proxyFunc returns the proxy to use for a request or nil when no proxy is configured
+	proxyFunc func(*url.URL) (*url.URL, error)
+
+	// trustedCA contains the PEM encoded certificates trusted for connections through the proxy
+	trustedCA []byte
+}
+
+// proxy returns the proxy to use for the request. The environment of the operator is used when the cluster does not
+// define a proxy
+func (p *clusterProxy) proxy(req *http.Request) (*url.URL, error) {
+
+	if p == nil || p.proxyFunc == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+
+	return p.proxyFunc(req.URL)
+}
+
+// getClusterProxy returns the cluster-wide Proxy configuration or nil when the Proxy API is not available or the
+// cluster Proxy does not exist. The trusted CA is read using the provided reader
+func (r *RepositoryReconciler) getClusterProxy(ctx context.Context, reader client.Reader) (*clusterProxy, error) {
+
+	if !r.proxyAvailable {
+		return nil, nil
+	}
+
+	proxy := &configv1.Proxy{}
+	err := r.GetClient().Get(ctx, k8stypes.NamespacedName{Name: clusterProxyName}, proxy)
+
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("Failed to GET proxy %s reason %v", clusterProxyName, err)
+	}
+
+	result := &clusterProxy{}
+
+	// The status contains the effective configuration including the cluster networks excluded from the proxy
+	if proxy.Status.HTTPProxy != "" || proxy.Status.HTTPSProxy != "" {
+		result.proxyFunc = (&httpproxy.Config{
+			HTTPProxy:  proxy.Status.HTTPProxy,
+			HTTPSProxy: proxy.Status.HTTPSProxy,
+			NoProxy:    proxy.Status.NoProxy,
+		}).ProxyFunc()
+	}
+
+	if proxy.Spec.TrustedCA.Name != "" {
+
+		configMap := &corev1.ConfigMap{}
+		err = reader.Get(ctx, k8stypes.NamespacedName{Name: proxy.Spec.TrustedCA.Name, Namespace: OpenShiftConfigNamespace}, configMap)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to GET proxy trusted CA configmap %s reason %v", proxy.Spec.TrustedCA.Name, err)
+		}
+
+		caCert, found := configMap.Data[caBundleKey]
+
+		if !found {
+			return nil, fmt.Errorf("Failed to find %s key in configmap %s", caBundleKey, proxy.Spec.TrustedCA.Name)
+		}
+
+		result.trustedCA = []byte(caCert)
+	}
+
+	return result, nil
+}
+
+// isClusterProxy filters events to the cluster-wide Proxy configuration
+func isClusterProxy() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == clusterProxyName
+	})
+}
+
+// isProxyTrustedCA determines whether the ConfigMap is referenced as the trusted CA of the cluster Proxy
+func (r *RepositoryReconciler) isProxyTrustedCA(configMap client.Object) bool {
+
+	if !r.proxyAvailable || configMap.GetNamespace() != OpenShiftConfigNamespace {
+		return false
+	}
+
+	proxy := &configv1.Proxy{}
+	err := r.GetClient().Get(context.Background(), k8stypes.NamespacedName{Name: clusterProxyName}, proxy)
+
+	return err == nil && proxy.Spec.TrustedCA.Name == configMap.GetName()
+}
+
+// findAllRepositories returns requests for every repository, as changes to the cluster Proxy or to chart filters can
+// affect all repositories
+func (r *HelmChartRepositoryReconciler) findAllRepositories(obj client.Object) []reconcile.Request {
+
+	helmChartRepositories := &helmv1beta1.HelmChartRepositoryList{}
+	err := r.GetClient().List(context.Background(), helmChartRepositories)
+
+	if err != nil {
+		r.Log.Error(err, "Failed to List Repositories")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for _, helmChartRepository := range helmChartRepositories.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: helmChartRepository.Name}})
+	}
+
+	r.Log.Info("Configuration Affecting All Repositories Changed", "Kind", fmt.Sprintf("%T", obj), "Name", obj.GetName(), "Repositories", len(requests))
+
+	return requests
+}
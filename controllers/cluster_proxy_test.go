@@ -0,0 +1,157 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetClusterProxy(t *testing.T) {
+
+	newProxy := func(trustedCAName string) *configv1.Proxy {
+		return &configv1.Proxy{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterProxyName},
+			Spec: configv1.ProxySpec{
+				HTTPSProxy: "http://spec-proxy.example.com:3128",
+				TrustedCA:  configv1.ConfigMapNameReference{Name: trustedCAName},
+			},
+			Status: configv1.ProxyStatus{
+				HTTPSProxy: "http://proxy.example.com:3128",
+				NoProxy:    ".cluster.local",
+			},
+		}
+	}
+
+	newTrustedCA := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "user-ca-bundle", Namespace: OpenShiftConfigNamespace}, Data: data}
+	}
+
+	tests := []struct {
+		name              string
+		proxyAvailable    bool
+		objs              []client.Object
+		expectProxy       bool
+		expectError       bool
+		expectedTrustedCA []byte
+	}{
+		{name: "proxy not available", proxyAvailable: false, objs: []client.Object{newProxy("")}, expectProxy: false},
+		{name: "cluster proxy not found", proxyAvailable: true, expectProxy: false},
+		{name: "cluster proxy", proxyAvailable: true, objs: []client.Object{newProxy("")}, expectProxy: true},
+		{
+			name:              "trusted CA",
+			proxyAvailable:    true,
+			objs:              []client.Object{newProxy("user-ca-bundle"), newTrustedCA(map[string]string{caBundleKey: "certificate"})},
+			expectProxy:       true,
+			expectedTrustedCA: []byte("certificate"),
+		},
+		{name: "trusted CA without the bundle key", proxyAvailable: true, objs: []client.Object{newProxy("user-ca-bundle"), newTrustedCA(map[string]string{"ca.crt": "certificate"})}, expectError: true},
+		{name: "missing trusted CA", proxyAvailable: true, objs: []client.Object{newProxy("user-ca-bundle")}, expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			r, _ := newTestReconciler(t, test.objs...)
+			r.proxyAvailable = test.proxyAvailable
+
+			proxy, err := r.getClusterProxy(context.Background(), r.GetClient())
+
+			if test.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (proxy != nil) != test.expectProxy {
+				t.Fatalf("expected a proxy %t, got %+v", test.expectProxy, proxy)
+			}
+
+			if proxy != nil && !reflect.DeepEqual(proxy.trustedCA, test.expectedTrustedCA) {
+				t.Errorf("expected the trusted CA %q, got %q", test.expectedTrustedCA, proxy.trustedCA)
+			}
+		})
+	}
+}
+
+func TestClusterProxy(t *testing.T) {
+
+	// The status of the cluster Proxy takes precedence over its spec
+	r, _ := newTestReconciler(t, &configv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterProxyName},
+		Spec:       configv1.ProxySpec{HTTPSProxy: "http://spec-proxy.example.com:3128"},
+		Status:     configv1.ProxyStatus{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: ".cluster.local"},
+	})
+	r.proxyAvailable = true
+
+	statusProxy, err := r.getClusterProxy(context.Background(), r.GetClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		proxy    *clusterProxy
+		url      string
+		expected string
+	}{
+		{name: "status proxy", proxy: statusProxy, url: "https://charts.example.com/index.yaml", expected: "http://proxy.example.com:3128"},
+		{name: "excluded host", proxy: statusProxy, url: "https://charts.svc.cluster.local/index.yaml", expected: ""},
+		{name: "no proxy configured", proxy: &clusterProxy{}, url: "https://charts.example.com/index.yaml", expected: "environment"},
+		{name: "no cluster proxy", proxy: nil, url: "https://charts.example.com/index.yaml", expected: "environment"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			req, err := http.NewRequest(http.MethodGet, test.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proxyURL, err := test.proxy.proxy(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The environment of the operator is used when the cluster does not define a proxy
+			var expectedURL *url.URL
+			if test.expected == "environment" {
+				expectedURL, _ = http.ProxyFromEnvironment(req)
+			} else if test.expected != "" {
+				expectedURL, _ = url.Parse(test.expected)
+			}
+
+			if !reflect.DeepEqual(proxyURL, expectedURL) {
+				t.Errorf("expected the proxy %v, got %v", expectedURL, proxyURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/url"
+
+	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// configMapIndexField indexes repositories by the names of the referenced ConfigMaps
+	configMapIndexField = "spec.connectionConfig.configMaps"
+
+	// secretIndexField indexes repositories by the names of the referenced Secrets
+	secretIndexField = "spec.connectionConfig.secrets"
+)
+
+// setupConfigIndexes registers the indexes used to find the repositories referencing a ConfigMap or Secret
+func setupConfigIndexes(mgr ctrl.Manager) error {
+
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &helmv1beta1.HelmChartRepository{}, configMapIndexField, referencedConfigMapNames)
+
+	if err != nil {
+		return err
+	}
+
+	return mgr.GetFieldIndexer().IndexField(context.Background(), &helmv1beta1.HelmChartRepository{}, secretIndexField, referencedSecretNames)
+}
+
+// referencedConfigMapNames returns the names of the ConfigMaps referenced by the repository
+func referencedConfigMapNames(obj client.Object) []string {
+	helmChartRepository := obj.(*helmv1beta1.HelmChartRepository)
+
+	configMapNames := []string{}
+
+	if helmChartRepository.Spec.ConnectionConfig.CA.Name != "" {
+		configMapNames = append(configMapNames, helmChartRepository.Spec.ConnectionConfig.CA.Name)
+	}
+
+	if sourceURL, err := url.Parse(helmChartRepository.GetAnnotations()[sourceURLAnnotation]); err == nil && sourceURL.Scheme == configMapScheme {
+		configMapName, _ := configMapIndexSource(sourceURL)
+		configMapNames = append(configMapNames, configMapName)
+	}
+
+	return configMapNames
+}
+
+// referencedSecretNames returns the names of the Secrets referenced by the repository
+func referencedSecretNames(obj client.Object) []string {
+	helmChartRepository := obj.(*helmv1beta1.HelmChartRepository)
+
+	secretNames := []string{}
+
+	if helmChartRepository.Spec.ConnectionConfig.TLSClientConfig.Name != "" {
+		secretNames = append(secretNames, helmChartRepository.Spec.ConnectionConfig.TLSClientConfig.Name)
+	}
+
+	if authSecret := helmChartRepository.GetAnnotations()[authSecretAnnotation]; authSecret != "" {
+		secretNames = append(secretNames, authSecret)
+	}
+
+	return secretNames
+}
+
+// setupConfigCache creates the cache containing the ConfigMaps and Secrets of the configuration namespace, allowing
+// the operator to only be granted access to that namespace. The cache also contains the OpenShift configuration
+// namespace when the cluster Proxy is available as it contains the trusted CA of the Proxy
+func (r *HelmChartRepositoryReconciler) setupConfigCache(mgr ctrl.Manager) error {
+
+	namespaces := []string{r.ConfigNamespace}
+
+	if r.proxyAvailable && r.ConfigNamespace != OpenShiftConfigNamespace {
+		namespaces = append(namespaces, OpenShiftConfigNamespace)
+	}
+
+	configCache, err := cache.MultiNamespacedCacheBuilder(namespaces)(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+
+	if err != nil {
+		return err
+	}
+
+	r.configCache = configCache
+
+	return mgr.Add(configCache)
+}
+
+// findRepositoriesForConfigMap returns requests for the repositories referencing the ConfigMap. Every repository is
+// requested when the ConfigMap contains the trusted CA of the cluster Proxy
+func (r *HelmChartRepositoryReconciler) findRepositoriesForConfigMap(obj client.Object) []reconcile.Request {
+
+	if r.isProxyTrustedCA(obj) {
+		return r.findAllRepositories(obj)
+	}
+
+	if obj.GetNamespace() != r.ConfigNamespace {
+		return nil
+	}
+
+	return r.findRepositoriesByIndex(configMapIndexField, obj.GetName())
+}
+
+// findRepositoriesForSecret returns requests for the repositories referencing the Secret
+func (r *HelmChartRepositoryReconciler) findRepositoriesForSecret(obj client.Object) []reconcile.Request {
+
+	if obj.GetNamespace() != r.ConfigNamespace {
+		return nil
+	}
+
+	return r.findRepositoriesByIndex(secretIndexField, obj.GetName())
+}
+
+func (r *HelmChartRepositoryReconciler) findRepositoriesByIndex(indexField string, name string) []reconcile.Request {
+
+	helmChartRepositories := &helmv1beta1.HelmChartRepositoryList{}
+	err := r.GetClient().List(context.Background(), helmChartRepositories, client.MatchingFields{indexField: name})
+
+	if err != nil {
+		r.Log.Error(err, "Failed to List Repositories Referencing Configuration", "Index", indexField, "Name", name)
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for _, helmChartRepository := range helmChartRepositories.Items {
+		r.Log.Info("Referenced Configuration Changed", "Repository", helmChartRepository.Name, "Name", name)
+		requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: helmChartRepository.Name}})
+	}
+
+	return requests
+}
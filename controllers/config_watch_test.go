@@ -0,0 +1,104 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReferencedConfigNames(t *testing.T) {
+
+	tests := []struct {
+		name                   string
+		connectionConfig       helmv1beta1.ConnectionConfig
+		annotations            map[string]string
+		expectedConfigMapNames []string
+		expectedSecretNames    []string
+	}{
+		{
+			name:                   "no references",
+			expectedConfigMapNames: []string{},
+			expectedSecretNames:    []string{},
+		},
+		{
+			name: "CA and TLS client configuration",
+			connectionConfig: helmv1beta1.ConnectionConfig{
+				CA:              configv1.ConfigMapNameReference{Name: "repository-ca"},
+				TLSClientConfig: configv1.SecretNameReference{Name: "repository-tls"},
+			},
+			expectedConfigMapNames: []string{"repository-ca"},
+			expectedSecretNames:    []string{"repository-tls"},
+		},
+		{
+			name: "annotations",
+			connectionConfig: helmv1beta1.ConnectionConfig{
+				CA: configv1.ConfigMapNameReference{Name: "repository-ca"},
+			},
+			annotations: map[string]string{
+				sourceURLAnnotation:  "configmap://chart-index/charts.yaml",
+				authSecretAnnotation: "repository-credentials",
+			},
+			expectedConfigMapNames: []string{"repository-ca", "chart-index"},
+			expectedSecretNames:    []string{"repository-credentials"},
+		},
+		{
+			name:                   "source URL of another scheme",
+			annotations:            map[string]string{sourceURLAnnotation: "https://mirror.example.com/charts"},
+			expectedConfigMapNames: []string{},
+			expectedSecretNames:    []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			helmChartRepository := &helmv1beta1.HelmChartRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "repository", Annotations: test.annotations},
+				Spec:       helmv1beta1.HelmChartRepositorySpec{ConnectionConfig: test.connectionConfig},
+			}
+
+			if configMapNames := referencedConfigMapNames(helmChartRepository); !reflect.DeepEqual(configMapNames, test.expectedConfigMapNames) {
+				t.Errorf("expected ConfigMaps %v, got %v", test.expectedConfigMapNames, configMapNames)
+			}
+
+			if secretNames := referencedSecretNames(helmChartRepository); !reflect.DeepEqual(secretNames, test.expectedSecretNames) {
+				t.Errorf("expected Secrets %v, got %v", test.expectedSecretNames, secretNames)
+			}
+		})
+	}
+}
+
+func TestFindRepositoriesOutsideConfigNamespace(t *testing.T) {
+
+	r := &HelmChartRepositoryReconciler{RepositoryReconciler{ConfigNamespace: "helm-config"}}
+
+	objectMeta := metav1.ObjectMeta{Name: "repository-ca", Namespace: "other"}
+
+	if requests := r.findRepositoriesForConfigMap(&corev1.ConfigMap{ObjectMeta: objectMeta}); requests != nil {
+		t.Errorf("expected no requests for a ConfigMap outside the configuration namespace, got %v", requests)
+	}
+
+	if requests := r.findRepositoriesForSecret(&corev1.Secret{ObjectMeta: objectMeta}); requests != nil {
+		t.Errorf("expected no requests for a Secret outside the configuration namespace, got %v", requests)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	usernameSecretKey = "username"
+	passwordSecretKey = "password"
+	tokenSecretKey    = "token"
+)
+
+// repositoryCredentials represents the credentials used to authenticate against a repository
+type repositoryCredentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// getRepositoryCredentials reads the credentials contained in the named Secret within the configuration namespace of
+// the repository. A token takes precedence over a username and password
+func (r *RepositoryReconciler) getRepositoryCredentials(ctx context.Context, repository *chartRepository, secretName string) (*repositoryCredentials, error) {
+
+	secret := &corev1.Secret{}
+	err := repository.configReader.Get(ctx, k8stypes.NamespacedName{Name: secretName, Namespace: repository.configNamespace}, secret)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to GET secret %s reason %v", secretName, err)
+	}
+
+	if token, ok := secret.Data[tokenSecretKey]; ok && len(token) > 0 {
+		return &repositoryCredentials{Token: string(token)}, nil
+	}
+
+	username, usernameOk := secret.Data[usernameSecretKey]
+	password, passwordOk := secret.Data[passwordSecretKey]
+
+	if !usernameOk || !passwordOk {
+		return nil, fmt.Errorf("Failed to find %s key or %s and %s keys in secret %s", tokenSecretKey, usernameSecretKey, passwordSecretKey, secretName)
+	}
+
+	return &repositoryCredentials{Username: string(username), Password: string(password)}, nil
+}
+
+// credentialsTransport adds repository credentials to requests sent to the repository host. Credentials are only sent
+// to other hosts, such as the target of a redirect, when passCredentialsAll is set
+type credentialsTransport struct {
+	base               http.RoundTripper
+	credentials        *repositoryCredentials
+	host               string
+	passCredentialsAll bool
+}
+
+func (t *credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	if !t.passCredentialsAll && req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+
+	if t.credentials.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.credentials.Token)
+	} else {
+		req.SetBasicAuth(t.credentials.Username, t.credentials.Password)
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"testing"
+)
+
+// roundTripperFunc adapts a function to an http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCredentialsTransport(t *testing.T) {
+
+	tests := []struct {
+		name                  string
+		credentials           *repositoryCredentials
+		passCredentialsAll    bool
+		url                   string
+		expectedAuthorization string
+	}{
+		{
+			name:                  "basic authentication on the repository host",
+			credentials:           &repositoryCredentials{Username: "user", Password: "secret"},
+			url:                   "https://charts.example.com/index.yaml",
+			expectedAuthorization: "Basic dXNlcjpzZWNyZXQ=",
+		},
+		{
+			name:                  "bearer token on the repository host",
+			credentials:           &repositoryCredentials{Token: "token"},
+			url:                   "https://charts.example.com/index.yaml",
+			expectedAuthorization: "Bearer token",
+		},
+		{
+			name:        "other host",
+			credentials: &repositoryCredentials{Token: "token"},
+			url:         "https://cdn.example.com/nginx-1.0.0.tgz",
+		},
+		{
+			name:                  "other host passing credentials",
+			credentials:           &repositoryCredentials{Token: "token"},
+			passCredentialsAll:    true,
+			url:                   "https://cdn.example.com/nginx-1.0.0.tgz",
+			expectedAuthorization: "Bearer token",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			var authorization string
+
+			transport := &credentialsTransport{
+				base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					authorization = req.Header.Get("Authorization")
+					return &http.Response{StatusCode: http.StatusOK}, nil
+				}),
+				credentials:        test.credentials,
+				host:               "charts.example.com",
+				passCredentialsAll: test.passCredentialsAll,
+			}
+
+			req, err := http.NewRequest(http.MethodGet, test.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if authorization != test.expectedAuthorization {
+				t.Errorf("expected authorization %q, got %q", test.expectedAuthorization, authorization)
+			}
+
+			if req.Header.Get("Authorization") != "" {
+				t.Error("expected the original request not to be modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/oci"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SynchronizedEventReason is the reason of the event recorded when the charts of a modified index were synchronized
+	SynchronizedEventReason = "Synchronized"
+
+	// FetchFailedEventReason is the reason of the event recorded when the repository index could not be retrieved
+	FetchFailedEventReason = "FetchFailed"
+
+	// TLSFailedEventReason is the reason of the event recorded when a secure connection to the repository could not be
+	// configured or established
+	TLSFailedEventReason = "TLSFailed"
+
+	// AuthenticationFailedEventReason is the reason of the event recorded when the repository credentials could not
+	// be read or were rejected
+	AuthenticationFailedEventReason = "AuthenticationFailed"
+
+	// ConfigurationInvalidEventReason is the reason of the event recorded when the repository URL is invalid
+	ConfigurationInvalidEventReason = "ConfigurationInvalid"
+
+	// IndexInvalidEventReason is the reason of the event recorded when the repository index could not be parsed
+	IndexInvalidEventReason = "IndexInvalid"
+
+	// ChartsFailedEventReason is the reason of the event recorded when individual charts could not be synchronized
+	ChartsFailedEventReason = "ChartsFailed"
+
+	// FilterInvalidEventReason is the reason of the event recorded on a chart filter whose repository selector is
+	// invalid
+	FilterInvalidEventReason = "FilterInvalid"
+
+	// VersionsAddedEventReason is the reason of the event recorded on a chart when versions were added
+	VersionsAddedEventReason = "VersionsAdded"
+
+	// VersionsRemovedEventReason is the reason of the event recorded on a chart when versions were removed
+	VersionsRemovedEventReason = "VersionsRemoved"
+
+	// maxEventVersions limits the number of versions listed in the message of an event
+	maxEventVersions = 10
+)
+
+// recordWarning records a Warning event for the object
+func (r *RepositoryReconciler) recordWarning(object client.Object, reason string, err error) {
+	r.GetRecorder().Event(object, corev1.EventTypeWarning, reason, err.Error())
+}
+
+// fetchFailedEventReason classifies an error encountered while retrieving the repository index
+func fetchFailedEventReason(err error) string {
+
+	statusCode := 0
+
+	var statusErr *httpStatusError
+	var ociStatusErr *oci.StatusError
+
+	if errors.As(err, &statusErr) {
+		statusCode = statusErr.statusCode
+	} else if errors.As(err, &ociStatusErr) {
+		statusCode = ociStatusErr.StatusCode
+	}
+
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		return AuthenticationFailedEventReason
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var certificateInvalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var recordHeaderErr tls.RecordHeaderError
+
+	if errors.As(err, &unknownAuthorityErr) || errors.As(err, &certificateInvalidErr) || errors.As(err, &hostnameErr) || errors.As(err, &recordHeaderErr) {
+		return TLSFailedEventReason
+	}
+
+	return FetchFailedEventReason
+}
+
+// recordVersionEvents records events on the chart for the versions that were added or removed compared to the
+// existing versions
+func (r *RepositoryReconciler) recordVersionEvents(helmChart client.Object, existingVersions []string, versions []string) {
+
+	existingVersionSet := map[string]struct{}{}
+	for _, version := range existingVersions {
+		existingVersionSet[version] = struct{}{}
+	}
+
+	versionSet := map[string]struct{}{}
+	for _, version := range versions {
+		versionSet[version] = struct{}{}
+	}
+
+	addedVersions := []string{}
+	for _, version := range versions {
+		if _, found := existingVersionSet[version]; !found {
+			addedVersions = append(addedVersions, version)
+		}
+	}
+
+	removedVersions := []string{}
+	for _, version := range existingVersions {
+		if _, found := versionSet[version]; !found {
+			removedVersions = append(removedVersions, version)
+		}
+	}
+
+	if len(addedVersions) > 0 {
+		r.GetRecorder().Event(helmChart, corev1.EventTypeNormal, VersionsAddedEventReason, fmt.Sprintf("Added versions %s", formatEventVersions(addedVersions)))
+	}
+
+	if len(removedVersions) > 0 {
+		r.GetRecorder().Event(helmChart, corev1.EventTypeNormal, VersionsRemovedEventReason, fmt.Sprintf("Removed versions %s", formatEventVersions(removedVersions)))
+	}
+}
+
+// formatEventVersions lists the versions, truncating long lists to keep the message of the event readable
+func formatEventVersions(versions []string) string {
+
+	if len(versions) <= maxEventVersions {
+		return strings.Join(versions, ", ")
+	}
+
+	return fmt.Sprintf("%s and %d more", strings.Join(versions[:maxEventVersions], ", "), len(versions)-maxEventVersions)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/oci"
+	"github.com/redhat-cop/operator-utils/pkg/util"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestFetchFailedEventReason(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "unauthorized", err: &httpStatusError{statusCode: http.StatusUnauthorized}, expected: AuthenticationFailedEventReason},
+		{name: "forbidden", err: fmt.Errorf("Failed to fetch index: %w", &httpStatusError{statusCode: http.StatusForbidden}), expected: AuthenticationFailedEventReason},
+		{name: "registry unauthorized", err: &oci.StatusError{StatusCode: http.StatusUnauthorized}, expected: AuthenticationFailedEventReason},
+		{name: "not found", err: &httpStatusError{statusCode: http.StatusNotFound}, expected: FetchFailedEventReason},
+		{name: "unknown certificate authority", err: fmt.Errorf("Get failed: %w", x509.UnknownAuthorityError{}), expected: TLSFailedEventReason},
+		{name: "hostname mismatch", err: x509.HostnameError{Host: "example.com", Certificate: &x509.Certificate{}}, expected: TLSFailedEventReason},
+		{name: "other error", err: fmt.Errorf("connection refused"), expected: FetchFailedEventReason},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if reason := fetchFailedEventReason(test.err); reason != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, reason)
+			}
+		})
+	}
+}
+
+func TestRecordVersionEvents(t *testing.T) {
+
+	manyVersions := []string{}
+	for i := 0; i < maxEventVersions+2; i++ {
+		manyVersions = append(manyVersions, fmt.Sprintf("1.0.%d", i))
+	}
+
+	tests := []struct {
+		name             string
+		existingVersions []string
+		versions         []string
+		expected         []string
+	}{
+		{name: "unchanged", existingVersions: []string{"1.0.0"}, versions: []string{"1.0.0"}, expected: []string{}},
+		{name: "added", existingVersions: []string{"1.0.0"}, versions: []string{"1.1.0", "1.0.0"}, expected: []string{"Normal VersionsAdded Added versions 1.1.0"}},
+		{name: "removed", existingVersions: []string{"1.0.0", "0.9.0"}, versions: []string{"1.0.0"}, expected: []string{"Normal VersionsRemoved Removed versions 0.9.0"}},
+		{
+			name:             "added and removed",
+			existingVersions: []string{"1.0.0"},
+			versions:         []string{"1.1.0"},
+			expected:         []string{"Normal VersionsAdded Added versions 1.1.0", "Normal VersionsRemoved Removed versions 1.0.0"},
+		},
+		{
+			name:             "many versions",
+			existingVersions: []string{},
+			versions:         manyVersions,
+			expected:         []string{"Normal VersionsAdded Added versions " + strings.Join(manyVersions[:maxEventVersions], ", ") + " and 2 more"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			recorder := record.NewFakeRecorder(10)
+			r := &RepositoryReconciler{ReconcilerBase: util.NewReconcilerBase(nil, recorder)}
+
+			r.recordVersionEvents(&redhatcopv1alpha1.HelmChart{}, test.existingVersions, test.versions)
+			close(recorder.Events)
+
+			events := []string{}
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+
+			if strings.Join(events, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("expected the events %q, got %q", test.expected, events)
+			}
+		})
+	}
+}
@@ -18,56 +18,130 @@ package controllers
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
-	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
-	"strings"
 	"time"
 
-	"sigs.k8s.io/yaml"
-
-	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
-	"github.com/redhat-cop/helm-chart-repository-operator/pkg/types"
-	"github.com/redhat-cop/helm-chart-repository-operator/pkg/utils"
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
 
-	"github.com/redhat-cop/operator-utils/pkg/util"
-	"helm.sh/helm/v3/pkg/repo"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	k8stypes "k8s.io/apimachinery/pkg/types"
 	kubeclock "k8s.io/apimachinery/pkg/util/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// OpenShiftConfigNamespace is the namespace containing the cluster configuration and the default namespace
+	// containing the ConfigMaps and Secrets referenced by repositories
+	OpenShiftConfigNamespace = "openshift-config"
+
+	// lastCheckedRefreshPeriod is the maximum age of the last checked timestamp of an unchanged chart
+	lastCheckedRefreshPeriod = 24 * time.Hour
+
+	// chartRetryBaseDelay is the delay before charts that failed to synchronize are first retried
+	chartRetryBaseDelay = 10 * time.Second
+
+	// chartRetryMaxDoublings limits the number of times the chart retry delay is doubled
+	chartRetryMaxDoublings = 16
+
+	// maxReportedChartFailures limits the number of failed charts whose errors are included in the sync error, keeping
+	// the conditions, events and last error of repositories with many failing charts readable
+	maxReportedChartFailures = 10
+
+	// maxStatusChartFailures limits the number of failed charts listed in the sync status, keeping the status of
+	// repositories with many failing charts within the limits of the API server
+	maxStatusChartFailures = 100
+
+	// maxChartFailureMessageLength is the maximum length of the message of each failed chart listed in the sync status
+	maxChartFailureMessageLength = 1024
+
+	// maxConditionMessageLength is the maximum length of condition messages accepted by the API server
+	maxConditionMessageLength = 32768
 )
 
+// ChartCleanupPolicy determines how charts that are no longer served by a repository are handled
+type ChartCleanupPolicy string
+
 const (
-	configNamespace = "openshift-config"
+	// DeleteChartCleanupPolicy removes charts that are no longer served by the repository
+	DeleteChartCleanupPolicy ChartCleanupPolicy = "Delete"
+
+	// RetainChartCleanupPolicy keeps charts that are no longer served by the repository and flags them using a condition
+	RetainChartCleanupPolicy ChartCleanupPolicy = "Retain"
 )
 
 var clock kubeclock.Clock = &kubeclock.RealClock{}
 
+// indexParseError represents a repository index that could be retrieved but not parsed
+type indexParseError struct {
+	err error
+}
+
+func (e *indexParseError) Error() string {
+	return fmt.Sprintf("Failed to parse repository index: %v", e.err)
+}
+
+func (e *indexParseError) Unwrap() error {
+	return e.err
+}
+
+// httpStatusError represents a repository that responded with an unexpected status code
+type httpStatusError struct {
+	url        string
+	statusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("Response for %v returned status code %v", e.url, e.statusCode)
+}
+
+// chartSyncError represents individual charts of a repository that failed to synchronize. The error only contains
+// the errors of the first failed charts
+type chartSyncError struct {
+	err        error
+	count      int
+	retryAfter time.Duration
+}
+
+func (e *chartSyncError) Error() string {
+	if omitted := e.count - maxReportedChartFailures; omitted > 0 {
+		return fmt.Sprintf("Failed to synchronize %d charts: %v, and %d more", e.count, e.err, omitted)
+	}
+
+	return fmt.Sprintf("Failed to synchronize %d charts: %v", e.count, e.err)
+}
+
+func (e *chartSyncError) Unwrap() error {
+	return e.err
+}
+
 // HelmChartRepositoryReconciler reconciles a HelmChartRepository object
 type HelmChartRepositoryReconciler struct {
-	util.ReconcilerBase
-	Log             logr.Logger
-	ReconcilePeriod int
-	ServerVersion   string
+	RepositoryReconciler
 }
 
 //+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=helmcharts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=helmcharts/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=helmcharts/finalizers,verbs=update
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=helmchartversions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=helmchartrepositorysyncs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=helmchartrepositorysyncs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=helm.openshift.io,resources=helmchartrepositories,verbs=get;list;watch
 //+kubebuilder:rbac:groups=helm.openshift.io,resources=helmchartrepositories/finalizers,verbs=update
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=helmchartfilters,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Secrets and ConfigMaps are read in every namespace, as project repositories reference them within their own namespace
+
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=proxies,verbs=get;list;watch
 
 func (r *HelmChartRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	_ = r.Log.WithValues("helmchartrepository", req.NamespacedName)
@@ -77,6 +151,7 @@ func (r *HelmChartRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.
 
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			r.forgetRepository(req.Namespace, req.Name)
 			return reconcile.Result{}, nil
 		}
 
@@ -86,186 +161,52 @@ func (r *HelmChartRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.
 
 	r.Log.Info("Reconciling Helm Chart Repository", "Name", instance.Name)
 
-	if !instance.Spec.Disabled {
-
-		var indexFile repo.IndexFile
-
-		httpClient, err := r.getHttpClient(ctx, instance)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-
-		repositoryURL, err := url.Parse(instance.Spec.ConnectionConfig.URL)
-		if err != nil {
-			return reconcile.Result{}, errors.New(fmt.Sprintf("Unable to parse repository URL %v", instance.Spec.ConnectionConfig.URL))
-		}
-
-		indexURL := repositoryURL.String()
-		if !strings.HasSuffix(indexURL, "/index.yaml") {
-			indexURL += "/index.yaml"
-		}
-		resp, err := httpClient.Get(indexURL)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-		if resp.StatusCode != 200 {
-			return reconcile.Result{}, errors.New(fmt.Sprintf("Response for %v returned %v with status code %v", indexURL, resp, resp.StatusCode))
-		}
-
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-		err = yaml.Unmarshal(body, &indexFile)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-		for _, chartVersions := range indexFile.Entries {
-			for _, chartVersion := range chartVersions {
-				for i, url := range chartVersion.URLs {
-					chartVersion.URLs[i], err = repo.ResolveReferenceURL(indexURL, url)
-					if err != nil {
-						r.Log.Error(err, "Error resolving chart url", instance.Name)
-					}
-				}
-			}
-		}
-
-		// Sort Entries
-		indexFile.SortEntries()
-
-		for chartName, versions := range indexFile.Entries {
-
-			helmChart, err := utils.MapToHelmChart(&types.HelmChartEntry{Name: chartName, Repository: instance, ChartVersions: versions, ServerVersion: r.ServerVersion})
-
-			if err != nil {
-				r.Log.Error(err, "Failed to map to Helm Chart")
-				return reconcile.Result{}, err
-			}
-
-			err = r.CreateOrUpdateResource(ctx, instance, "", helmChart)
-
-			if err != nil {
-				r.Log.Error(err, "Failed to Update Chart", "Name", helmChart.Name)
-				return reconcile.Result{}, err
-			}
-
-			helmChart.Status.LastUpdateTimestamp = &metav1.Time{Time: clock.Now()}
-
-			err = r.GetClient().Status().Update(ctx, helmChart)
-
-			if err != nil {
-				r.Log.Error(err, "Failed to Update Chart Status", "Name", helmChart.Name)
-				return reconcile.Result{}, err
-			}
-
-		}
-
-	} else {
-		r.Log.Info("Skipping Disabled Chart Repository", "Name", instance.Name)
-	}
-
-	return ctrl.Result{Requeue: true, RequeueAfter: time.Second * time.Duration(r.ReconcilePeriod)}, nil
+	return r.reconcileRepository(ctx, &chartRepository{
+		object:              instance,
+		disabled:            instance.Spec.Disabled,
+		displayName:         instance.Spec.DisplayName,
+		url:                 instance.Spec.ConnectionConfig.URL,
+		caName:              instance.Spec.ConnectionConfig.CA.Name,
+		tlsClientConfigName: instance.Spec.ConnectionConfig.TLSClientConfig.Name,
+		configNamespace:     r.ConfigNamespace,
+		configReader:        r.configCache,
+		allowLocalFiles:     true,
+	})
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *HelmChartRepositoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
-	discoveryClient, err := r.GetDiscoveryClient()
+	err := r.setup()
 
 	if err != nil {
 		return err
 	}
 
-	serverVersion, err := discoveryClient.ServerVersion()
+	err = r.setupConfigCache(mgr)
 
 	if err != nil {
 		return err
 	}
 
-	r.ServerVersion = serverVersion.String()
-
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&helmv1beta1.HelmChartRepository{}).
-		Complete(r)
-}
-
-func (r *HelmChartRepositoryReconciler) getHttpClient(ctx context.Context, helmChartRepository *helmv1beta1.HelmChartRepository) (*http.Client, error) {
-
-	var err error
-
-	var rootCAs *x509.CertPool
-
-	if (helmChartRepository.Spec.ConnectionConfig.CA != configv1.ConfigMapNameReference{}) {
-		caName := helmChartRepository.Spec.ConnectionConfig.CA.Name
-
-		configMap := &corev1.ConfigMap{}
-		err = r.GetClient().Get(ctx, k8stypes.NamespacedName{Name: caName, Namespace: configNamespace}, configMap)
-
-		if err != nil {
-			r.Log.Error(err, "Unable to access ConfigMap from OpenShift Config Namespace", "Name", helmChartRepository.Spec.ConnectionConfig.CA.Name)
-		}
-		caBundleKey := "ca-bundle.crt"
-		caCert, found := configMap.Data[caBundleKey]
-
-		if !found {
-			return nil, errors.New(fmt.Sprintf("Failed to find %s key in configmap %s", caBundleKey, caName))
-		}
-
-		if caCert != "" {
-			rootCAs = x509.NewCertPool()
-			if ok := rootCAs.AppendCertsFromPEM([]byte(caCert)); !ok {
-				return nil, errors.New("Failed to append caCert")
-			}
-		}
-	}
-
-	if rootCAs == nil {
-		rootCAs, err = x509.SystemCertPool()
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	tlsClientConfig := utils.SecureTLSConfig(&tls.Config{
-		RootCAs: rootCAs,
-	})
-
-	if (helmChartRepository.Spec.ConnectionConfig.TLSClientConfig != configv1.SecretNameReference{}) {
-
-		secretName := helmChartRepository.Spec.ConnectionConfig.TLSClientConfig.Name
-
-		secret := &corev1.Secret{}
-		err := r.GetClient().Get(ctx, k8stypes.NamespacedName{Name: secretName, Namespace: configNamespace}, secret)
-
-		if err != nil {
-			return nil, errors.New(fmt.Sprintf("Failed to GET secret %s reason %v", secretName, err))
-		}
-		tlsCertSecretKey := "tls.crt"
-		tlsCert, ok := secret.Data[tlsCertSecretKey]
-		if !ok {
-			return nil, errors.New(fmt.Sprintf("Failed to find %s key in secret %s", tlsCertSecretKey, secretName))
-		}
-		tlsSecretKey := "tls.key"
-		tlsKey, ok := secret.Data[tlsSecretKey]
-		if !ok {
-			return nil, errors.New(fmt.Sprintf("Failed to find %s key in secret %s", tlsSecretKey, secretName))
-		}
-		if tlsKey != nil && tlsCert != nil {
-			cert, err := tls.X509KeyPair(tlsCert, tlsKey)
-			if err != nil {
-				return nil, err
-			}
-			tlsClientConfig.Certificates = []tls.Certificate{cert}
-		}
+	err = setupConfigIndexes(mgr)
 
+	if err != nil {
+		return err
 	}
 
-	tr := &http.Transport{
-		TLSClientConfig: tlsClientConfig,
-		Proxy:           http.ProxyFromEnvironment,
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&helmv1beta1.HelmChartRepository{}).
+		Watches(&source.Kind{Type: &redhatcopv1alpha1.HelmChart{}}, handler.EnqueueRequestsFromMapFunc(r.findRepositoryForHelmChart), builder.WithPredicates(r.isModifiedOutsideOperator())).
+		Watches(&source.Kind{Type: &redhatcopv1alpha1.HelmChartVersion{}}, handler.EnqueueRequestsFromMapFunc(r.findRepositoryForHelmChartVersion), builder.WithPredicates(r.isModifiedOutsideOperator())).
+		Watches(source.NewKindWithCache(&corev1.ConfigMap{}, r.configCache), handler.EnqueueRequestsFromMapFunc(r.findRepositoriesForConfigMap)).
+		Watches(source.NewKindWithCache(&corev1.Secret{}, r.configCache), handler.EnqueueRequestsFromMapFunc(r.findRepositoriesForSecret)).
+		Watches(&source.Kind{Type: &redhatcopv1alpha1.HelmChartFilter{}}, handler.EnqueueRequestsFromMapFunc(r.findAllRepositories), builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+
+	// The cluster Proxy is only available on OpenShift
+	if r.proxyAvailable {
+		controllerBuilder = controllerBuilder.Watches(&source.Kind{Type: &configv1.Proxy{}}, handler.EnqueueRequestsFromMapFunc(r.findAllRepositories), builder.WithPredicates(isClusterProxy()))
 	}
 
-	return &http.Client{Transport: tr}, nil
-
+	return controllerBuilder.Complete(r)
 }
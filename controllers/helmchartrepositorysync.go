@@ -0,0 +1,157 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/types"
+	"github.com/redhat-cop/operator-utils/pkg/util/apis"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// getHelmChartRepositorySync returns the sync status resource of the repository, creating it when it does not exist.
+// Project repositories record their status in a ProjectHelmChartRepositorySync within the same namespace
+func (r *RepositoryReconciler) getHelmChartRepositorySync(ctx context.Context, repository *chartRepository) (types.HelmChartRepositorySyncObject, error) {
+
+	var helmChartRepositorySync types.HelmChartRepositorySyncObject
+
+	if repository.namespace() != "" {
+		helmChartRepositorySync = &redhatcopv1alpha1.ProjectHelmChartRepositorySync{}
+	} else {
+		helmChartRepositorySync = &redhatcopv1alpha1.HelmChartRepositorySync{}
+	}
+
+	err := r.GetClient().Get(ctx, k8stypes.NamespacedName{Namespace: repository.namespace(), Name: repository.name()}, helmChartRepositorySync)
+
+	if err == nil {
+		return helmChartRepositorySync, nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if repository.namespace() != "" {
+		helmChartRepositorySync = &redhatcopv1alpha1.ProjectHelmChartRepositorySync{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "ProjectHelmChartRepositorySync",
+				APIVersion: redhatcopv1alpha1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      repository.name(),
+				Namespace: repository.namespace(),
+			},
+			Spec: redhatcopv1alpha1.HelmChartRepositorySyncSpec{
+				RepositoryName: repository.name(),
+			},
+		}
+	} else {
+		helmChartRepositorySync = &redhatcopv1alpha1.HelmChartRepositorySync{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "HelmChartRepositorySync",
+				APIVersion: redhatcopv1alpha1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: repository.name(),
+			},
+			Spec: redhatcopv1alpha1.HelmChartRepositorySyncSpec{
+				RepositoryName: repository.name(),
+			},
+		}
+	}
+
+	err = controllerutil.SetControllerReference(repository.object, helmChartRepositorySync, r.GetScheme())
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.GetClient().Create(ctx, helmChartRepositorySync)
+
+	return helmChartRepositorySync, err
+}
+
+// recordSyncOutcome records the result of a synchronization attempt in the sync status resource
+func (r *RepositoryReconciler) recordSyncOutcome(ctx context.Context, helmChartRepositorySync types.HelmChartRepositorySyncObject, syncErr error) error {
+
+	now := &metav1.Time{Time: clock.Now()}
+
+	helmChartRepositorySync.GetSyncStatus().LastSyncTimestamp = now
+
+	if syncErr != nil {
+		reason := redhatcopv1alpha1.HelmChartRepositorySyncFailedReason
+
+		var chartErr *chartSyncError
+		if errors.As(syncErr, &chartErr) {
+			reason = redhatcopv1alpha1.HelmChartRepositorySyncChartsFailedReason
+		}
+
+		helmChartRepositorySync.GetSyncStatus().LastError = truncateMessage(syncErr.Error())
+		setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncSynced, metav1.ConditionFalse, reason, syncErr.Error())
+	} else {
+		helmChartRepositorySync.GetSyncStatus().LastError = ""
+		helmChartRepositorySync.GetSyncStatus().LastSuccessfulSyncTimestamp = now
+		setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncSynced, metav1.ConditionTrue, redhatcopv1alpha1.HelmChartRepositorySyncSucceededReason, "")
+	}
+
+	return r.GetClient().Status().Update(ctx, helmChartRepositorySync)
+}
+
+// setSyncCondition adds or replaces a condition, retaining the transition time when the status has not changed
+func setSyncCondition(helmChartRepositorySync types.HelmChartRepositorySyncObject, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+
+	lastTransitionTime := metav1.Now()
+
+	if condition, found := apis.GetCondition(conditionType, helmChartRepositorySync.GetConditions()); found && condition.Status == status {
+		lastTransitionTime = condition.LastTransitionTime
+	}
+
+	helmChartRepositorySync.SetConditions(apis.AddOrReplaceCondition(metav1.Condition{
+		Type:               conditionType,
+		LastTransitionTime: lastTransitionTime,
+		ObservedGeneration: helmChartRepositorySync.GetGeneration(),
+		Message:            truncateMessage(message),
+		Reason:             reason,
+		Status:             status,
+	}, helmChartRepositorySync.GetConditions()))
+}
+
+// truncateMessage shortens messages exceeding the maximum length of condition messages
+func truncateMessage(message string) string {
+	return truncateString(message, maxConditionMessageLength)
+}
+
+// truncateString shortens messages exceeding maxLength characters, ending them with an ellipsis
+func truncateString(message string, maxLength int) string {
+
+	// The maximum length is measured in characters rather than bytes
+	runes := []rune(message)
+
+	if len(runes) <= maxLength {
+		return message
+	}
+
+	const ellipsis = "..."
+
+	return string(runes[:maxLength-len(ellipsis)]) + ellipsis
+}
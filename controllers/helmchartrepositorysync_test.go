@@ -0,0 +1,120 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordSyncOutcome(t *testing.T) {
+
+	fakeClock := setTestClock(t)
+
+	r, _ := newTestReconciler(t)
+	repository := newTestRepository(t, r, "https://example.com", nil)
+
+	helmChartRepositorySync, err := r.getHelmChartRepositorySync(context.Background(), repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	successTime := fakeClock.Now()
+
+	tests := []struct {
+		name           string
+		syncErr        error
+		expectedReason string
+		expectedError  string
+	}{
+		{name: "succeeded", syncErr: nil, expectedReason: redhatcopv1alpha1.HelmChartRepositorySyncSucceededReason, expectedError: ""},
+		{name: "failed", syncErr: errors.New("connection refused"), expectedReason: redhatcopv1alpha1.HelmChartRepositorySyncFailedReason, expectedError: "connection refused"},
+		{
+			name:           "charts failed",
+			syncErr:        &chartSyncError{err: errors.New("nginx: invalid version"), count: 1},
+			expectedReason: redhatcopv1alpha1.HelmChartRepositorySyncChartsFailedReason,
+			expectedError:  "Failed to synchronize 1 charts: nginx: invalid version",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			if err := r.recordSyncOutcome(context.Background(), helmChartRepositorySync, test.syncErr); err != nil {
+				t.Fatal(err)
+			}
+
+			status := getTestSyncStatus(t, r, repository).Status
+
+			if !status.LastSyncTimestamp.Time.Equal(fakeClock.Now()) || status.LastError != test.expectedError {
+				t.Errorf("expected the last sync at %v with error %q, got %+v", fakeClock.Now(), test.expectedError, status)
+			}
+
+			// The last successful synchronization is retained when synchronizations fail
+			if !status.LastSuccessfulSyncTimestamp.Time.Equal(successTime) {
+				t.Errorf("expected the last successful sync at %v, got %v", successTime, status.LastSuccessfulSyncTimestamp)
+			}
+
+			condition := meta.FindStatusCondition(status.Conditions, redhatcopv1alpha1.HelmChartRepositorySyncSynced)
+
+			if condition == nil || condition.Reason != test.expectedReason || (condition.Status == metav1.ConditionTrue) != (test.syncErr == nil) {
+				t.Errorf("expected the %s reason, got %+v", test.expectedReason, condition)
+			}
+
+			fakeClock.Step(time.Minute)
+		})
+	}
+}
+
+func TestSetSyncCondition(t *testing.T) {
+
+	helmChartRepositorySync := &redhatcopv1alpha1.HelmChartRepositorySync{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	previousTransitionTime := metav1.NewTime(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	helmChartRepositorySync.Status.Conditions = []metav1.Condition{{
+		Type:               redhatcopv1alpha1.HelmChartRepositorySyncReachable,
+		Status:             metav1.ConditionTrue,
+		Reason:             redhatcopv1alpha1.HelmChartRepositorySyncIndexRetrievedReason,
+		LastTransitionTime: previousTransitionTime,
+	}}
+
+	setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionTrue, redhatcopv1alpha1.HelmChartRepositorySyncIndexRetrievedReason, "")
+
+	condition := meta.FindStatusCondition(helmChartRepositorySync.Status.Conditions, redhatcopv1alpha1.HelmChartRepositorySyncReachable)
+
+	if !condition.LastTransitionTime.Equal(&previousTransitionTime) || condition.ObservedGeneration != 2 {
+		t.Errorf("expected the transition time to be retained and the generation to be observed, got %+v", condition)
+	}
+
+	setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncFetchFailedReason, "connection refused")
+
+	condition = meta.FindStatusCondition(helmChartRepositorySync.Status.Conditions, redhatcopv1alpha1.HelmChartRepositorySyncReachable)
+
+	if condition.LastTransitionTime.Equal(&previousTransitionTime) || condition.Status != metav1.ConditionFalse || condition.Message != "connection refused" {
+		t.Errorf("expected the condition to transition, got %+v", condition)
+	}
+
+	if len(helmChartRepositorySync.Status.Conditions) != 1 {
+		t.Errorf("expected the condition to be replaced, got %+v", helmChartRepositorySync.Status.Conditions)
+	}
+}
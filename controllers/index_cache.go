@@ -0,0 +1,238 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/filter"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/oci"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/types"
+	"helm.sh/helm/v3/pkg/repo"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+// indexCacheEntry represents the most recently synchronized index of a repository
+type indexCacheEntry struct {
+	// URL is the location the index was retrieved from
+	URL string
+
+	// Generation is the generation of the repository the index was synchronized for
+	Generation int64
+
+	// ETag is the entity tag returned by the server along with the index
+	ETag string
+
+	// LastModified is the modification time returned by the server along with the index
+	LastModified string
+
+	// IndexFile is the parsed index
+	IndexFile *repo.IndexFile
+
+	// Size is the size of the index in bytes. The size of the chart metadata is used for OCI registries
+	Size int
+
+	// Manifests contains the chart manifests the index of an OCI registry was built from
+	Manifests []*oci.Manifest
+
+	// FailedCharts contains the charts of the index that failed to synchronize keyed by chart name
+	FailedCharts map[string]error
+
+	// RetryCount is the number of times the failed charts have been retried
+	RetryCount int
+
+	// LastSync is the time the index was last synchronized
+	LastSync time.Time
+
+	// SelectionApplied is the time the chart selection was last applied to all charts of the index
+	SelectionApplied time.Time
+
+	// Retention contains the version retention settings the charts of the index were synchronized with
+	Retention versionRetentionOptions
+
+	// Filter contains the chart filters the charts of the index were synchronized with
+	Filter *filter.ChartFilter
+
+	// Visibility contains the prerelease and deprecation settings the charts of the index were synchronized with
+	Visibility versionVisibilityOptions
+
+	// Credentials describes the credentials used to download the charts of the index. Nil when the repository does not
+	// require credentials
+	Credentials *types.ChartCredentials
+}
+
+// chartSelection returns the settings selecting the charts and versions of the index that are synchronized, along with
+// the credentials used to download them
+func (e *indexCacheEntry) chartSelection(now time.Time) *chartSelection {
+	return &chartSelection{
+		retention:   e.Retention.versionRetention(now),
+		filter:      e.Filter,
+		visibility:  e.Visibility,
+		credentials: e.Credentials,
+	}
+}
+
+// versionsExpired returns whether a version of the index exceeded the maximum age of the retention settings since the
+// chart selection was last applied
+func (e *indexCacheEntry) versionsExpired(now time.Time) bool {
+	if e.Retention.MaxAge <= 0 || e.IndexFile == nil {
+		return false
+	}
+
+	for _, chartVersions := range e.IndexFile.Entries {
+		for _, chartVersion := range chartVersions {
+			if chartVersion.Created.IsZero() {
+				continue
+			}
+
+			if expiry := chartVersion.Created.Add(e.Retention.MaxAge); expiry.After(e.SelectionApplied) && !expiry.After(now) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// indexCache stores the most recently synchronized index keyed by repository name
+type indexCache struct {
+	mutex   sync.RWMutex
+	entries map[string]*indexCacheEntry
+}
+
+func newIndexCache() *indexCache {
+	return &indexCache{
+		entries: map[string]*indexCacheEntry{},
+	}
+}
+
+// Get returns the cached index of the repository
+func (c *indexCache) Get(name string) (*indexCacheEntry, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entry, found := c.entries[name]
+
+	return entry, found
+}
+
+// Set stores the index of the repository
+func (c *indexCache) Set(name string, entry *indexCacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[name] = entry
+}
+
+// Delete removes the index of the repository
+func (c *indexCache) Delete(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.entries, name)
+}
+
+// dirtyCharts tracks charts that have been modified outside of the operator keyed by repository name
+type dirtyCharts struct {
+	mutex  sync.Mutex
+	charts map[string]map[string]struct{}
+}
+
+func newDirtyCharts() *dirtyCharts {
+	return &dirtyCharts{
+		charts: map[string]map[string]struct{}{},
+	}
+}
+
+// Add marks the chart of the repository as modified
+func (d *dirtyCharts) Add(repositoryName string, chartName string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, found := d.charts[repositoryName]; !found {
+		d.charts[repositoryName] = map[string]struct{}{}
+	}
+
+	d.charts[repositoryName][chartName] = struct{}{}
+}
+
+// Take returns and clears the modified charts of the repository
+func (d *dirtyCharts) Take(repositoryName string) []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	chartNames := []string{}
+
+	for chartName := range d.charts[repositoryName] {
+		chartNames = append(chartNames, chartName)
+	}
+
+	delete(d.charts, repositoryName)
+
+	return chartNames
+}
+
+// Delete clears the modified charts of the repository
+func (d *dirtyCharts) Delete(repositoryName string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.charts, repositoryName)
+}
+
+// ownDeletions tracks the charts and chart versions deleted by the operator keyed by UID, so their deletion is not
+// mistaken for a modification made outside of the operator
+type ownDeletions struct {
+	mutex sync.Mutex
+	uids  map[k8stypes.UID]struct{}
+}
+
+func newOwnDeletions() *ownDeletions {
+	return &ownDeletions{
+		uids: map[k8stypes.UID]struct{}{},
+	}
+}
+
+// Add records that the object is being deleted by the operator
+func (d *ownDeletions) Add(uid k8stypes.UID) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.uids[uid] = struct{}{}
+}
+
+// Take returns whether the object was deleted by the operator and forgets it
+func (d *ownDeletions) Take(uid k8stypes.UID) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	_, found := d.uids[uid]
+	delete(d.uids, uid)
+
+	return found
+}
+
+// Contains returns whether the object is being deleted by the operator without forgetting it
+func (d *ownDeletions) Contains(uid k8stypes.UID) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	_, found := d.uids[uid]
+
+	return found
+}
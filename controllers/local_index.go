@@ -0,0 +1,174 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// fileScheme identifies an index file mounted into the operator
+	fileScheme = "file"
+
+	// configMapScheme identifies an index stored in a ConfigMap within the configuration namespace of the repository
+	configMapScheme = "configmap"
+
+	indexFileName = "index.yaml"
+)
+
+// isLocalURLScheme determines whether the scheme references an index that is available without network access
+func isLocalURLScheme(scheme string) bool {
+	return scheme == fileScheme || scheme == configMapScheme
+}
+
+// configMapIndexSource returns the name of the ConfigMap and the key containing the index referenced by a
+// configmap://<name>[/<key>] URL. The index.yaml key is used when no key is specified
+func configMapIndexSource(sourceURL *url.URL) (string, string) {
+
+	key := strings.Trim(sourceURL.Path, "/")
+
+	if key == "" {
+		key = indexFileName
+	}
+
+	return sourceURL.Host, key
+}
+
+// fetchLocalIndexFile reads the index referenced by a file:// or configmap:// URL. The digest of the content serves
+// as the ETag of the index so modified is only reported as true when the content has changed
+func (r *RepositoryReconciler) fetchLocalIndexFile(ctx context.Context, repository *chartRepository, sourceURL *url.URL) (*indexCacheEntry, bool, error) {
+
+	var body []byte
+	var err error
+
+	if sourceURL.Scheme == configMapScheme {
+		body, err = r.readConfigMapIndex(ctx, repository, sourceURL)
+	} else {
+		body, err = r.readFileIndex(sourceURL)
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	digest := fmt.Sprintf("%x", sha256.Sum256(body))
+
+	cacheEntry, cached := r.indexCache.Get(repository.key())
+
+	// Cached entries are only valid for the same location and repository configuration
+	if cached && cacheEntry.URL == sourceURL.String() && cacheEntry.Generation == repository.object.GetGeneration() && cacheEntry.ETag == digest {
+		return cacheEntry, false, nil
+	}
+
+	// Relative chart URLs cannot be resolved against a local source and are kept as is
+	indexFile, err := r.parseIndexFile(repository, body, "")
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &indexCacheEntry{
+		URL:        sourceURL.String(),
+		Generation: repository.object.GetGeneration(),
+		ETag:       digest,
+		IndexFile:  indexFile,
+		Size:       len(body),
+	}, true, nil
+}
+
+func (r *RepositoryReconciler) readConfigMapIndex(ctx context.Context, repository *chartRepository, sourceURL *url.URL) ([]byte, error) {
+
+	configMapName, key := configMapIndexSource(sourceURL)
+
+	configMap := &corev1.ConfigMap{}
+	err := repository.configReader.Get(ctx, k8stypes.NamespacedName{Name: configMapName, Namespace: repository.configNamespace}, configMap)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to GET configmap %s reason %v", configMapName, err)
+	}
+
+	if data, found := configMap.Data[key]; found {
+		return []byte(data), nil
+	}
+
+	if binaryData, found := configMap.BinaryData[key]; found {
+		return binaryData, nil
+	}
+
+	return nil, fmt.Errorf("Failed to find %s key in configmap %s", key, configMapName)
+}
+
+// readFileIndex reads an index file located within the local index directory
+func (r *RepositoryReconciler) readFileIndex(sourceURL *url.URL) ([]byte, error) {
+
+	if r.LocalIndexDirectory == "" {
+		return nil, fmt.Errorf("Local index files are not enabled")
+	}
+
+	indexPath, err := resolveLocalIndexPath(r.LocalIndexDirectory, sourceURL.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := os.Stat(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if fileInfo.Size() > int64(r.MaxIndexSize) {
+		return nil, fmt.Errorf("Index file %s exceeds the maximum size of %d bytes", sourceURL.Path, r.MaxIndexSize)
+	}
+
+	return ioutil.ReadFile(indexPath)
+}
+
+// resolveLocalIndexPath returns the location of the index file referenced by the path, which must be located within
+// the local index directory. A path referencing a directory is resolved to the index.yaml file it contains
+func resolveLocalIndexPath(localIndexDirectory string, path string) (string, error) {
+
+	indexPath := filepath.Clean(path)
+
+	if filepath.Ext(indexPath) == "" {
+		indexPath = filepath.Join(indexPath, indexFileName)
+	}
+
+	// Symbolic links are resolved so files mounted from volumes can be read without escaping the directory
+	indexDirectory, err := filepath.EvalSymlinks(localIndexDirectory)
+	if err != nil {
+		return "", err
+	}
+
+	resolvedIndexPath, err := filepath.EvalSymlinks(indexPath)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(resolvedIndexPath, indexDirectory+string(filepath.Separator)) {
+		return "", fmt.Errorf("Index file %s is not located within %s", indexPath, localIndexDirectory)
+	}
+
+	return resolvedIndexPath, nil
+}
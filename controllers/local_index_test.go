@@ -0,0 +1,169 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigMapIndexSource(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		url          string
+		expectedName string
+		expectedKey  string
+	}{
+		{name: "default key", url: "configmap://chart-index", expectedName: "chart-index", expectedKey: indexFileName},
+		{name: "default key with trailing slash", url: "configmap://chart-index/", expectedName: "chart-index", expectedKey: indexFileName},
+		{name: "key", url: "configmap://chart-index/charts.yaml", expectedName: "chart-index", expectedKey: "charts.yaml"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			sourceURL, err := url.Parse(test.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			name, key := configMapIndexSource(sourceURL)
+
+			if name != test.expectedName || key != test.expectedKey {
+				t.Errorf("expected ConfigMap %s and key %s, got %s and %s", test.expectedName, test.expectedKey, name, key)
+			}
+		})
+	}
+}
+
+func TestResolveLocalIndexPath(t *testing.T) {
+
+	indexDirectory := t.TempDir()
+	outsideDirectory := t.TempDir()
+
+	for _, path := range []string{
+		filepath.Join(indexDirectory, indexFileName),
+		filepath.Join(indexDirectory, "charts", indexFileName),
+		filepath.Join(outsideDirectory, indexFileName),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte("apiVersion: v1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink(filepath.Join(outsideDirectory, indexFileName), filepath.Join(indexDirectory, "escape.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(filepath.Join(indexDirectory, "charts"), filepath.Join(indexDirectory, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		expected      string
+		expectedError string
+	}{
+		{name: "index file", path: filepath.Join(indexDirectory, indexFileName), expected: filepath.Join(indexDirectory, indexFileName)},
+		{name: "directory", path: filepath.Join(indexDirectory, "charts"), expected: filepath.Join(indexDirectory, "charts", indexFileName)},
+		{name: "symbolic link within directory", path: filepath.Join(indexDirectory, "linked"), expected: filepath.Join(indexDirectory, "charts", indexFileName)},
+		{name: "outside directory", path: filepath.Join(outsideDirectory, indexFileName), expectedError: "is not located within"},
+		{name: "parent reference", path: filepath.Join(indexDirectory, "..", filepath.Base(outsideDirectory), indexFileName), expectedError: "is not located within"},
+		{name: "symbolic link outside directory", path: filepath.Join(indexDirectory, "escape.yaml"), expectedError: "is not located within"},
+		{name: "index directory", path: indexDirectory, expected: filepath.Join(indexDirectory, indexFileName)},
+		{name: "missing file", path: filepath.Join(indexDirectory, "missing.yaml"), expectedError: "no such file or directory"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			indexPath, err := resolveLocalIndexPath(indexDirectory, test.path)
+
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Errorf("expected error containing %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected, _ := filepath.EvalSymlinks(test.expected)
+
+			if indexPath != expected {
+				t.Errorf("expected %s, got %s", expected, indexPath)
+			}
+		})
+	}
+}
+
+func TestReadFileIndex(t *testing.T) {
+
+	indexDirectory := t.TempDir()
+	indexPath := filepath.Join(indexDirectory, indexFileName)
+
+	if err := ioutil.WriteFile(indexPath, []byte("apiVersion: v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                string
+		localIndexDirectory string
+		maxIndexSize        int
+		expectedError       string
+	}{
+		{name: "enabled", localIndexDirectory: indexDirectory, maxIndexSize: 1024},
+		{name: "not enabled", maxIndexSize: 1024, expectedError: "not enabled"},
+		{name: "maximum size exceeded", localIndexDirectory: indexDirectory, maxIndexSize: 4, expectedError: "exceeds the maximum size"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			r := &RepositoryReconciler{LocalIndexDirectory: test.localIndexDirectory, MaxIndexSize: test.maxIndexSize}
+
+			data, err := r.readFileIndex(&url.URL{Scheme: fileScheme, Path: indexPath})
+
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Errorf("expected error containing %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(data) != "apiVersion: v1\n" {
+				t.Errorf("unexpected index %q", data)
+			}
+		})
+	}
+}
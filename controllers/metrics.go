@@ -0,0 +1,124 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespaceLabel  = "repository_namespace"
+	repositoryLabel = "repository"
+	reasonLabel     = "reason"
+)
+
+// syncFailureReasons contains the reasons used to count failed synchronizations
+var syncFailureReasons = []string{
+	FetchFailedEventReason,
+	TLSFailedEventReason,
+	AuthenticationFailedEventReason,
+	ConfigurationInvalidEventReason,
+	IndexInvalidEventReason,
+	ChartsFailedEventReason,
+}
+
+var (
+	repositorySyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "helmchart_repository_sync_duration_seconds",
+		Help:    "Duration of repository synchronizations in seconds",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{namespaceLabel, repositoryLabel})
+
+	repositorySyncFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "helmchart_repository_sync_failures_total",
+		Help: "Number of failed repository synchronizations by reason",
+	}, []string{namespaceLabel, repositoryLabel, reasonLabel})
+
+	repositoryIndexBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "helmchart_repository_index_bytes",
+		Help: "Size of the most recently retrieved repository index in bytes",
+	}, []string{namespaceLabel, repositoryLabel})
+
+	repositoryCharts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "helmchart_charts",
+		Help: "Number of charts synchronized from the repository",
+	}, []string{namespaceLabel, repositoryLabel})
+
+	repositoryVersions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "helmchart_versions",
+		Help: "Number of chart versions synchronized from the repository",
+	}, []string{namespaceLabel, repositoryLabel})
+
+	repositoryLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "helmchart_repository_last_success_timestamp",
+		Help: "Unix time of the last successful synchronization of the repository",
+	}, []string{namespaceLabel, repositoryLabel})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		repositorySyncDuration,
+		repositorySyncFailures,
+		repositoryIndexBytes,
+		repositoryCharts,
+		repositoryVersions,
+		repositoryLastSuccess,
+	)
+}
+
+// recordSyncFailure records a Warning event for the repository and counts the failure using the same reason
+func (r *RepositoryReconciler) recordSyncFailure(repository *chartRepository, reason string, err error) {
+	r.recordWarning(repository.object, reason, err)
+	repositorySyncFailures.WithLabelValues(repository.namespace(), repository.name(), reason).Inc()
+}
+
+// observeSync records the duration and outcome of a synchronization along with the size of the catalog
+func (r *RepositoryReconciler) observeSync(repository *chartRepository, helmChartRepositorySync types.HelmChartRepositorySyncObject, duration time.Duration, syncErr error) {
+
+	repositorySyncDuration.WithLabelValues(repository.namespace(), repository.name()).Observe(duration.Seconds())
+
+	if cacheEntry, found := r.indexCache.Get(repository.key()); found {
+		repositoryIndexBytes.WithLabelValues(repository.namespace(), repository.name()).Set(float64(cacheEntry.Size))
+	}
+
+	repositoryCharts.WithLabelValues(repository.namespace(), repository.name()).Set(float64(helmChartRepositorySync.GetSyncStatus().ChartCount))
+	repositoryVersions.WithLabelValues(repository.namespace(), repository.name()).Set(float64(helmChartRepositorySync.GetSyncStatus().VersionCount))
+
+	if syncErr == nil {
+		repositoryLastSuccess.WithLabelValues(repository.namespace(), repository.name()).Set(float64(clock.Now().Unix()))
+	}
+}
+
+// deleteRepositoryMetrics removes the metrics of a repository that is no longer synchronized
+func deleteRepositoryMetrics(namespace string, name string) {
+
+	labels := prometheus.Labels{namespaceLabel: namespace, repositoryLabel: name}
+
+	repositorySyncDuration.Delete(labels)
+	repositoryIndexBytes.Delete(labels)
+	repositoryCharts.Delete(labels)
+	repositoryVersions.Delete(labels)
+	repositoryLastSuccess.Delete(labels)
+
+	for _, reason := range syncFailureReasons {
+		repositorySyncFailures.Delete(prometheus.Labels{namespaceLabel: namespace, repositoryLabel: name, reasonLabel: reason})
+	}
+}
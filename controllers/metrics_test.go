@@ -0,0 +1,104 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	projecthelmv1beta1 "github.com/redhat-cop/helm-chart-repository-operator/pkg/apis/helm/v1beta1"
+	"github.com/redhat-cop/operator-utils/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestObserveSync(t *testing.T) {
+
+	fakeClock := setTestClock(t)
+
+	r := &RepositoryReconciler{
+		ReconcilerBase: util.NewReconcilerBase(nil, record.NewFakeRecorder(10)),
+		indexCache:     newIndexCache(),
+	}
+	repository := &chartRepository{object: &projecthelmv1beta1.ProjectHelmChartRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "my-project", Name: "observed"}}}
+	defer deleteRepositoryMetrics("my-project", "observed")
+
+	helmChartRepositorySync := &redhatcopv1alpha1.ProjectHelmChartRepositorySync{}
+	helmChartRepositorySync.Status.ChartCount = 2
+	helmChartRepositorySync.Status.VersionCount = 5
+
+	r.indexCache.Set(repository.key(), &indexCacheEntry{Size: 1024})
+	r.observeSync(repository, helmChartRepositorySync, time.Second, nil)
+
+	expected := map[string]float64{
+		"index bytes":  1024,
+		"charts":       2,
+		"versions":     5,
+		"last success": float64(fakeClock.Now().Unix()),
+	}
+
+	actual := map[string]float64{
+		"index bytes":  testutil.ToFloat64(repositoryIndexBytes.WithLabelValues("my-project", "observed")),
+		"charts":       testutil.ToFloat64(repositoryCharts.WithLabelValues("my-project", "observed")),
+		"versions":     testutil.ToFloat64(repositoryVersions.WithLabelValues("my-project", "observed")),
+		"last success": testutil.ToFloat64(repositoryLastSuccess.WithLabelValues("my-project", "observed")),
+	}
+
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("expected %s %v, got %v", name, value, actual[name])
+		}
+	}
+
+	// Failed synchronizations do not update the last success
+	fakeClock.Step(time.Minute)
+	r.observeSync(repository, helmChartRepositorySync, time.Second, errors.New("connection refused"))
+
+	if lastSuccess := testutil.ToFloat64(repositoryLastSuccess.WithLabelValues("my-project", "observed")); lastSuccess != expected["last success"] {
+		t.Errorf("expected the last success to be retained, got %v", lastSuccess)
+	}
+}
+
+func TestDeleteRepositoryMetrics(t *testing.T) {
+
+	r := &RepositoryReconciler{ReconcilerBase: util.NewReconcilerBase(nil, record.NewFakeRecorder(10))}
+
+	for _, name := range []string{"deleted", "retained"} {
+		repository := &chartRepository{object: &projecthelmv1beta1.ProjectHelmChartRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "my-project", Name: name}}}
+
+		repositorySyncDuration.WithLabelValues("my-project", name).Observe(1)
+		repositoryCharts.WithLabelValues("my-project", name).Set(1)
+		r.recordSyncFailure(repository, FetchFailedEventReason, errors.New("connection refused"))
+	}
+
+	deleteRepositoryMetrics("my-project", "deleted")
+
+	// Deleting a series reports whether it still existed
+	for _, name := range []string{"deleted", "retained"} {
+		expected := name == "retained"
+		labels := prometheus.Labels{namespaceLabel: "my-project", repositoryLabel: name}
+		failureLabels := prometheus.Labels{namespaceLabel: "my-project", repositoryLabel: name, reasonLabel: FetchFailedEventReason}
+
+		if repositorySyncDuration.Delete(labels) != expected || repositoryCharts.Delete(labels) != expected || repositorySyncFailures.Delete(failureLabels) != expected {
+			t.Errorf("expected the metrics of repository %s to exist %t", name, expected)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/oci"
+)
+
+// fetchOCIIndexFile enumerates the charts stored in an OCI registry and builds an index from their metadata. The
+// digest of the chart manifests serves as the ETag of the index so the chart metadata is only retrieved again and
+// modified reported as true when the content of the registry has changed
+func (r *RepositoryReconciler) fetchOCIIndexFile(ctx context.Context, repository *chartRepository, httpClient *http.Client, credentials *repositoryCredentials, options *repositoryOptions) (*indexCacheEntry, bool, error) {
+
+	var registryCredentials *oci.Credentials
+
+	if credentials != nil {
+		registryCredentials = &oci.Credentials{
+			Username: credentials.Username,
+			Password: credentials.Password,
+			Token:    credentials.Token,
+		}
+	}
+
+	registryClient, err := oci.NewClient(httpClient, options.URL, registryCredentials, options.PlainHTTP, int64(r.MaxIndexSize))
+	if err != nil {
+		return nil, false, err
+	}
+
+	cacheEntry, cached := r.indexCache.Get(repository.key())
+
+	// Manifests of the cached index are revalidated rather than retrieved again
+	var knownManifests []*oci.Manifest
+	if cached && cacheEntry.URL == options.URL {
+		knownManifests = cacheEntry.Manifests
+	}
+
+	manifests, err := registryClient.ListManifests(ctx, knownManifests)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Manifests of other artifacts are cached so they are not retrieved again, but are not part of the index
+	charts := oci.Charts(manifests)
+	digest := oci.Digest(charts)
+
+	// Cached entries are only valid for the same location and repository configuration
+	// The revalidated manifests are set on a copy, as the cached entry is only replaced once the charts were synchronized
+	if cached && cacheEntry.URL == options.URL && cacheEntry.Generation == repository.object.GetGeneration() && cacheEntry.ETag == digest {
+		revalidatedEntry := *cacheEntry
+		revalidatedEntry.Manifests = manifests
+		return &revalidatedEntry, false, nil
+	}
+
+	indexFile, err := registryClient.IndexFile(ctx, charts)
+	if errors.Is(err, oci.ErrInvalidMetadata) {
+		return nil, false, &indexParseError{err: err}
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	size := 0
+	for _, manifest := range charts {
+		size += int(manifest.Config.Size)
+	}
+
+	return &indexCacheEntry{
+		URL:        options.URL,
+		Generation: repository.object.GetGeneration(),
+		ETag:       digest,
+		IndexFile:  indexFile,
+		Size:       size,
+		Manifests:  manifests,
+	}, true, nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	projecthelmv1beta1 "github.com/redhat-cop/helm-chart-repository-operator/pkg/apis/helm/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var projectHelmChartRepositoryGVK = projecthelmv1beta1.GroupVersion.WithKind("ProjectHelmChartRepository")
+
+// ProjectHelmChartRepositoryReconciler reconciles a ProjectHelmChartRepository object
+type ProjectHelmChartRepositoryReconciler struct {
+	RepositoryReconciler
+}
+
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=projecthelmcharts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=projecthelmcharts/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=projecthelmcharts/finalizers,verbs=update
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=projecthelmchartversions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=projecthelmchartrepositorysyncs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=redhatcop.redhat.io,resources=projecthelmchartrepositorysyncs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=helm.openshift.io,resources=projecthelmchartrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups=helm.openshift.io,resources=projecthelmchartrepositories/finalizers,verbs=update
+
+func (r *ProjectHelmChartRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_ = r.Log.WithValues("projecthelmchartrepository", req.NamespacedName)
+
+	instance := &projecthelmv1beta1.ProjectHelmChartRepository{}
+	err := r.GetClient().Get(ctx, req.NamespacedName, instance)
+
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forgetRepository(req.Namespace, req.Name)
+			return reconcile.Result{}, nil
+		}
+
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	r.Log.Info("Reconciling Project Helm Chart Repository", "Name", instance.Name, "Namespace", instance.Namespace)
+
+	return r.reconcileRepository(ctx, r.newChartRepository(instance))
+}
+
+// newChartRepository returns the repository synchronized for a project repository. Its configuration is read from its
+// own namespace using the API reader, as the configuration cache is limited to the configuration namespace. Local index
+// files are not available as the operator would expose its own files to the users of the namespace
+func (r *ProjectHelmChartRepositoryReconciler) newChartRepository(instance *projecthelmv1beta1.ProjectHelmChartRepository) *chartRepository {
+	return &chartRepository{
+		object:              instance,
+		disabled:            instance.Spec.Disabled,
+		displayName:         instance.Spec.DisplayName,
+		url:                 instance.Spec.ProjectConnectionConfig.URL,
+		caName:              instance.Spec.ProjectConnectionConfig.CA.Name,
+		tlsClientConfigName: instance.Spec.ProjectConnectionConfig.TLSClientConfig.Name,
+		basicAuthConfigName: instance.Spec.ProjectConnectionConfig.BasicAuthConfig.Name,
+		configNamespace:     instance.Namespace,
+		configReader:        r.GetAPIReader(),
+		allowLocalFiles:     false,
+	}
+}
+
+// findAllProjectRepositories returns requests for every project repository, as changes to the cluster Proxy or to
+// chart filters can affect all repositories
+func (r *ProjectHelmChartRepositoryReconciler) findAllProjectRepositories(obj client.Object) []reconcile.Request {
+
+	projectHelmChartRepositories := &projecthelmv1beta1.ProjectHelmChartRepositoryList{}
+	err := r.GetClient().List(context.Background(), projectHelmChartRepositories)
+
+	if err != nil {
+		r.Log.Error(err, "Failed to List Project Repositories")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for _, projectHelmChartRepository := range projectHelmChartRepositories.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: k8stypes.NamespacedName{Namespace: projectHelmChartRepository.Namespace, Name: projectHelmChartRepository.Name}})
+	}
+
+	r.Log.Info("Configuration Affecting All Repositories Changed", "Kind", fmt.Sprintf("%T", obj), "Name", obj.GetName(), "ProjectRepositories", len(requests))
+
+	return requests
+}
+
+// findProjectRepositoriesForConfigMap returns requests for every project repository when the ConfigMap contains the
+// trusted CA of the cluster Proxy
+func (r *ProjectHelmChartRepositoryReconciler) findProjectRepositoriesForConfigMap(obj client.Object) []reconcile.Request {
+
+	if !r.isProxyTrustedCA(obj) {
+		return nil
+	}
+
+	return r.findAllProjectRepositories(obj)
+}
+
+// SetupWithManager sets up the controller with the Manager. The controller is not started when the
+// ProjectHelmChartRepository API is not available
+func (r *ProjectHelmChartRepositoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+
+	available, err := r.IsAPIResourceAvailable(projectHelmChartRepositoryGVK)
+
+	if err != nil {
+		return err
+	}
+
+	if !available {
+		r.Log.Info("Project Helm Chart Repositories are not available", "GroupVersionKind", projectHelmChartRepositoryGVK)
+		return nil
+	}
+
+	err = r.setup()
+
+	if err != nil {
+		return err
+	}
+
+	// ConfigMaps and Secrets of project repositories are read when the repository is synchronized rather than watched
+	// as they can be located in any namespace
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&projecthelmv1beta1.ProjectHelmChartRepository{}).
+		Watches(&source.Kind{Type: &redhatcopv1alpha1.ProjectHelmChart{}}, handler.EnqueueRequestsFromMapFunc(r.findRepositoryForHelmChart), builder.WithPredicates(r.isModifiedOutsideOperator())).
+		Watches(&source.Kind{Type: &redhatcopv1alpha1.ProjectHelmChartVersion{}}, handler.EnqueueRequestsFromMapFunc(r.findRepositoryForHelmChartVersion), builder.WithPredicates(r.isModifiedOutsideOperator())).
+		Watches(&source.Kind{Type: &redhatcopv1alpha1.HelmChartFilter{}}, handler.EnqueueRequestsFromMapFunc(r.findAllProjectRepositories), builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+
+	// The cluster Proxy is only available on OpenShift. Its trusted CA is watched using a cache limited to the OpenShift
+	// configuration namespace
+	if r.proxyAvailable {
+		proxyCache, err := cache.New(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper(), Namespace: OpenShiftConfigNamespace})
+
+		if err != nil {
+			return err
+		}
+
+		err = mgr.Add(proxyCache)
+
+		if err != nil {
+			return err
+		}
+
+		controllerBuilder = controllerBuilder.
+			Watches(&source.Kind{Type: &configv1.Proxy{}}, handler.EnqueueRequestsFromMapFunc(r.findAllProjectRepositories), builder.WithPredicates(isClusterProxy())).
+			Watches(source.NewKindWithCache(&corev1.ConfigMap{}, proxyCache), handler.EnqueueRequestsFromMapFunc(r.findProjectRepositoriesForConfigMap))
+	}
+
+	return controllerBuilder.Complete(r)
+}
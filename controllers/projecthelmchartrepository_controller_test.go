@@ -0,0 +1,182 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	projecthelmv1beta1 "github.com/redhat-cop/helm-chart-repository-operator/pkg/apis/helm/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newTestProjectRepository returns a project repository of the URL within the my-project namespace
+func newTestProjectRepository(name string, repositoryURL string) *projecthelmv1beta1.ProjectHelmChartRepository {
+	return &projecthelmv1beta1.ProjectHelmChartRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "my-project",
+		},
+		Spec: projecthelmv1beta1.ProjectHelmChartRepositorySpec{
+			ProjectConnectionConfig: projecthelmv1beta1.ConnectionConfigNamespaceScoped{URL: repositoryURL},
+		},
+	}
+}
+
+// reconcileTestProjectRepository reconciles the project repository and returns its sync status
+func reconcileTestProjectRepository(t *testing.T, r *ProjectHelmChartRepositoryReconciler, instance *projecthelmv1beta1.ProjectHelmChartRepository) *redhatcopv1alpha1.ProjectHelmChartRepositorySync {
+
+	key := k8stypes.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	projectHelmChartRepositorySync := &redhatcopv1alpha1.ProjectHelmChartRepositorySync{}
+
+	if err := r.GetClient().Get(context.Background(), key, projectHelmChartRepositorySync); err != nil {
+		t.Fatal(err)
+	}
+
+	return projectHelmChartRepositorySync
+}
+
+func TestProjectRepositoryConfigurationReader(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(testIndex))
+	}))
+	defer server.Close()
+
+	instance := newTestProjectRepository("repository", server.URL)
+	instance.Spec.ProjectConnectionConfig.BasicAuthConfig.Name = "credentials"
+
+	// The Secret is only available from the API reader, as the configuration cache is limited to the configuration
+	// namespace
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "my-project"},
+		Data:       map[string][]byte{usernameSecretKey: []byte("user"), passwordSecretKey: []byte("secret")},
+	}
+
+	base, _ := newTestReconcilerWithAPIReader(t, []client.Object{secret}, instance)
+	r := &ProjectHelmChartRepositoryReconciler{RepositoryReconciler: *base}
+
+	repository := r.newChartRepository(instance)
+
+	if repository.configNamespace != "my-project" || repository.configReader != r.GetAPIReader() || repository.allowLocalFiles {
+		t.Fatalf("expected the configuration to be read from the namespace of the repository using the API reader, got %+v", repository)
+	}
+
+	projectHelmChartRepositorySync := reconcileTestProjectRepository(t, r, instance)
+
+	if !meta.IsStatusConditionTrue(projectHelmChartRepositorySync.Status.Conditions, redhatcopv1alpha1.HelmChartRepositorySyncSynced) {
+		t.Fatalf("expected the repository to be synced, got %+v", projectHelmChartRepositorySync.Status)
+	}
+
+	projectHelmChart := &redhatcopv1alpha1.ProjectHelmChart{}
+	if err := r.GetClient().Get(context.Background(), k8stypes.NamespacedName{Namespace: "my-project", Name: "repository.nginx"}, projectHelmChart); err != nil {
+		t.Errorf("expected the chart to be created within the namespace of the repository, got %v", err)
+	}
+}
+
+func TestProjectRepositoryLocalIndexFile(t *testing.T) {
+
+	localIndexDirectory := t.TempDir()
+	indexPath := filepath.Join(localIndexDirectory, indexFileName)
+
+	if err := ioutil.WriteFile(indexPath, []byte(testIndex), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	instance := newTestProjectRepository("repository", "file://"+indexPath)
+
+	base, _ := newTestReconciler(t, instance)
+	base.LocalIndexDirectory = localIndexDirectory
+	r := &ProjectHelmChartRepositoryReconciler{RepositoryReconciler: *base}
+
+	projectHelmChartRepositorySync := reconcileTestProjectRepository(t, r, instance)
+
+	condition := meta.FindStatusCondition(projectHelmChartRepositorySync.Status.Conditions, redhatcopv1alpha1.HelmChartRepositorySyncReachable)
+
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != redhatcopv1alpha1.HelmChartRepositorySyncConfigurationInvalidReason {
+		t.Errorf("expected the local index file to be rejected, got %+v", projectHelmChartRepositorySync.Status)
+	}
+
+	projectHelmCharts := &redhatcopv1alpha1.ProjectHelmChartList{}
+	if err := r.GetClient().List(context.Background(), projectHelmCharts); err != nil || len(projectHelmCharts.Items) != 0 {
+		t.Errorf("expected no charts, got %d charts and error %v", len(projectHelmCharts.Items), err)
+	}
+}
+
+func TestFindProjectRepositoriesForConfigMap(t *testing.T) {
+
+	proxy := &configv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterProxyName},
+		Spec:       configv1.ProxySpec{TrustedCA: configv1.ConfigMapNameReference{Name: "user-ca-bundle"}},
+	}
+
+	base, _ := newTestReconciler(t, proxy, newTestProjectRepository("repository", "https://example.com"), newTestProjectRepository("other", "https://example.com"))
+	r := &ProjectHelmChartRepositoryReconciler{RepositoryReconciler: *base}
+
+	tests := []struct {
+		name           string
+		namespace      string
+		configMapName  string
+		proxyAvailable bool
+		expected       int
+	}{
+		{name: "proxy trusted CA", namespace: OpenShiftConfigNamespace, configMapName: "user-ca-bundle", proxyAvailable: true, expected: 2},
+		{name: "other ConfigMap", namespace: OpenShiftConfigNamespace, configMapName: "other", proxyAvailable: true, expected: 0},
+		{name: "ConfigMap in another namespace", namespace: "my-project", configMapName: "user-ca-bundle", proxyAvailable: true, expected: 0},
+		{name: "proxy not available", namespace: OpenShiftConfigNamespace, configMapName: "user-ca-bundle", proxyAvailable: false, expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			r.proxyAvailable = test.proxyAvailable
+
+			requests := r.findProjectRepositoriesForConfigMap(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: test.namespace, Name: test.configMapName}})
+
+			if len(requests) != test.expected {
+				t.Errorf("expected %d requests, got %v", test.expected, requests)
+			}
+
+			for _, request := range requests {
+				if request.Namespace != "my-project" {
+					t.Errorf("expected requests for the project repositories, got %v", request)
+				}
+			}
+		})
+	}
+}
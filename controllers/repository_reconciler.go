@@ -0,0 +1,1485 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/go-logr/logr"
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/filter"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/oci"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/types"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/utils"
+
+	"github.com/redhat-cop/operator-utils/pkg/util"
+	"github.com/redhat-cop/operator-utils/pkg/util/apis"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// RepositoryReconciler contains the settings and state shared by the reconcilers of cluster and project scoped
+// repositories
+type RepositoryReconciler struct {
+	util.ReconcilerBase
+	Log                      logr.Logger
+	ReconcilePeriod          int
+	ServerVersion            string
+	OrphanedChartPolicy      ChartCleanupPolicy
+	DisabledRepositoryPolicy ChartCleanupPolicy
+	FailureBackoffMin        int
+	FailureBackoffMax        int
+	LocalIndexDirectory      string
+	ConfigNamespace          string
+	ConnectTimeout           int
+	ResponseTimeout          int
+	FetchRetries             int
+	MaxIndexSize             int
+	RetainMaxVersions        int
+	RetainMaxAgeDays         int
+	RetainLatestPatch        bool
+	proxyAvailable           bool
+	configCache              cache.Cache
+	indexCache               *indexCache
+	dirtyCharts              *dirtyCharts
+	ownDeletions             *ownDeletions
+	backoff                  *repositoryBackoff
+	schedule                 *syncSchedule
+}
+
+// chartRepository represents a cluster scoped HelmChartRepository or a namespaced ProjectHelmChartRepository
+type chartRepository struct {
+	object              client.Object
+	disabled            bool
+	displayName         string
+	url                 string
+	caName              string
+	tlsClientConfigName string
+	basicAuthConfigName string
+
+	// configNamespace is the namespace containing the ConfigMaps and Secrets referenced by the repository
+	configNamespace string
+
+	// configReader reads the ConfigMaps and Secrets referenced by the repository
+	configReader client.Reader
+
+	// allowLocalFiles determines whether the index of the repository can be read from the local index directory
+	allowLocalFiles bool
+}
+
+func (c *chartRepository) name() string {
+	return c.object.GetName()
+}
+
+// namespace returns the namespace of the repository, which is empty for cluster scoped repositories
+func (c *chartRepository) namespace() string {
+	return c.object.GetNamespace()
+}
+
+// key identifies the repository within the caches of the reconciler
+func (c *chartRepository) key() string {
+	return repositoryKey(c.namespace(), c.name())
+}
+
+// newHelmChart returns an empty chart of the kind created for the repository
+func (c *chartRepository) newHelmChart() types.HelmChartObject {
+
+	if c.namespace() != "" {
+		return &redhatcopv1alpha1.ProjectHelmChart{}
+	}
+
+	return &redhatcopv1alpha1.HelmChart{}
+}
+
+// repositoryKey returns the name of cluster scoped repositories and the namespace and name of project repositories
+func repositoryKey(namespace string, name string) string {
+
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + "/" + name
+}
+
+// setup initializes the state of the reconciler
+func (r *RepositoryReconciler) setup() error {
+
+	discoveryClient, err := r.GetDiscoveryClient()
+
+	if err != nil {
+		return err
+	}
+
+	serverVersion, err := discoveryClient.ServerVersion()
+
+	if err != nil {
+		return err
+	}
+
+	r.ServerVersion = serverVersion.String()
+	r.indexCache = newIndexCache()
+	r.dirtyCharts = newDirtyCharts()
+	r.ownDeletions = newOwnDeletions()
+	r.backoff = newRepositoryBackoff(time.Second*time.Duration(r.FailureBackoffMin), time.Second*time.Duration(r.FailureBackoffMax))
+	r.schedule = newSyncSchedule()
+
+	r.proxyAvailable, err = r.IsAPIResourceAvailable(proxyGVK)
+
+	return err
+}
+
+// forgetRepository removes the state retained for a repository that no longer exists
+func (r *RepositoryReconciler) forgetRepository(namespace string, name string) {
+	r.indexCache.Delete(repositoryKey(namespace, name))
+	r.dirtyCharts.Delete(repositoryKey(namespace, name))
+	r.backoff.Reset(repositoryKey(namespace, name))
+	r.schedule.Reset(repositoryKey(namespace, name))
+	deleteRepositoryMetrics(namespace, name)
+}
+
+// reconcileRepository synchronizes the charts of a repository
+func (r *RepositoryReconciler) reconcileRepository(ctx context.Context, repository *chartRepository) (ctrl.Result, error) {
+
+	instance := repository.object
+
+	// The charts are owned by the repository, so the garbage collector removes them once the repository is deleted
+	if util.IsBeingDeleted(instance) {
+		r.forgetRepository(repository.namespace(), repository.name())
+		return reconcile.Result{}, nil
+	}
+
+	helmChartRepositorySync, err := r.getHelmChartRepositorySync(ctx, repository)
+
+	if err != nil {
+		r.Log.Error(err, "Failed to Get Repository Sync Status", "Name", repository.key())
+		return reconcile.Result{}, err
+	}
+
+	options, optionErrs := r.getRepositoryOptions(repository)
+
+	if len(optionErrs) > 0 {
+		optionErr := utilerrors.NewAggregate(optionErrs)
+		r.Log.Error(optionErr, "Invalid Repository Annotations", "Name", repository.key())
+		setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncConfigurationValid, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncInvalidAnnotationsReason, optionErr.Error())
+	} else {
+		setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncConfigurationValid, metav1.ConditionTrue, redhatcopv1alpha1.HelmChartRepositorySyncValidAnnotationsReason, "")
+	}
+
+	if !repository.disabled {
+
+		dirtyChartNames := r.dirtyCharts.Take(repository.key())
+		refreshRequested := options.RefreshRequested != "" && options.RefreshRequested != helmChartRepositorySync.GetSyncStatus().LastHandledRefreshRequest
+
+		if refreshRequested {
+			r.Log.Info("Refresh Requested", "Name", repository.key(), "Request", options.RefreshRequested)
+
+			r.indexCache.Delete(repository.key())
+		} else if len(dirtyChartNames) > 0 {
+
+			// Restore modified charts from the cached index until the next synchronization is due
+			if cacheEntry, found := r.indexCache.Get(repository.key()); found && cacheEntry.Generation == instance.GetGeneration() {
+				if nextSync := options.SyncInterval - clock.Since(cacheEntry.LastSync); nextSync > 0 {
+					r.Log.Info("Restoring Modified Charts", "Name", repository.key(), "Count", len(dirtyChartNames))
+
+					_, failedCharts := r.applyHelmCharts(ctx, repository, cacheEntry.IndexFile, dirtyChartNames, cacheEntry.chartSelection(clock.Now()))
+
+					// Jitter retains the spread of the synchronization of repositories
+					requeueAfter := wait.Jitter(nextSync, jitterFactor)
+
+					if len(failedCharts) == 0 {
+						return reconcile.Result{RequeueAfter: requeueAfter}, nil
+					}
+
+					// Charts that failed to be restored are retried along with the charts that previously failed
+					r.Log.Info("Failed to Restore Charts", "Name", repository.key(), "Count", len(failedCharts))
+
+					if cacheEntry.FailedCharts == nil {
+						cacheEntry.FailedCharts = map[string]error{}
+					}
+
+					for chartName, chartErr := range failedCharts {
+						cacheEntry.FailedCharts[chartName] = chartErr
+					}
+
+					r.indexCache.Set(repository.key(), cacheEntry)
+
+					restoreErr := r.newChartSyncError(helmChartRepositorySync, cacheEntry.FailedCharts, cacheEntry.RetryCount, options.SyncInterval)
+					r.recordSyncFailure(repository, ChartsFailedEventReason, restoreErr)
+
+					err = r.recordSyncOutcome(ctx, helmChartRepositorySync, restoreErr)
+
+					if err != nil {
+						r.Log.Error(err, "Failed to Update Repository Sync Status", "Name", repository.key())
+					}
+
+					var chartErr *chartSyncError
+					if errors.As(restoreErr, &chartErr) && chartErr.retryAfter < requeueAfter {
+						requeueAfter = chartErr.retryAfter
+					}
+
+					return reconcile.Result{RequeueAfter: requeueAfter}, nil
+				}
+			}
+		}
+
+		syncStart := clock.Now()
+		syncErr := r.syncRepository(ctx, repository, helmChartRepositorySync, options, dirtyChartNames)
+		r.observeSync(repository, helmChartRepositorySync, clock.Since(syncStart), syncErr)
+
+		var chartErr *chartSyncError
+		isChartErr := errors.As(syncErr, &chartErr)
+
+		// A refresh is only acknowledged once the index was fetched again, so failed refreshes are retried
+		if refreshRequested && (syncErr == nil || isChartErr) {
+			helmChartRepositorySync.GetSyncStatus().LastHandledRefreshRequest = options.RefreshRequested
+		}
+
+		err = r.recordSyncOutcome(ctx, helmChartRepositorySync, syncErr)
+
+		if err != nil {
+			r.Log.Error(err, "Failed to Update Repository Sync Status", "Name", repository.key())
+		}
+
+		// Only the failed charts are retried so the error is not returned to avoid requeuing the entire repository
+		if isChartErr {
+			r.recordSyncFailure(repository, ChartsFailedEventReason, syncErr)
+			r.backoff.Reset(repository.key())
+			return reconcile.Result{RequeueAfter: chartErr.retryAfter}, nil
+		}
+
+		// Failures are retried using the repository backoff rather than the rate limiter of the controller. Modified
+		// charts are kept so they are restored by the next synchronization
+		if syncErr != nil {
+			for _, chartName := range dirtyChartNames {
+				r.dirtyCharts.Add(repository.key(), chartName)
+			}
+
+			retryAfter := r.backoff.Next(repository.key())
+			r.Log.Error(syncErr, "Failed to Synchronize Repository", "Name", repository.key(), "RetryAfter", retryAfter)
+			return reconcile.Result{RequeueAfter: retryAfter}, nil
+		}
+
+		r.backoff.Reset(repository.key())
+
+	} else {
+		r.Log.Info("Skipping Disabled Chart Repository", "Name", repository.key())
+
+		r.indexCache.Delete(repository.key())
+		deleteRepositoryMetrics(repository.namespace(), repository.name())
+
+		if r.DisabledRepositoryPolicy == RetainChartCleanupPolicy {
+			err = r.flagDisabledCharts(ctx, repository)
+		} else {
+			err = r.deleteHelmCharts(ctx, repository)
+		}
+
+		if err != nil {
+			r.Log.Error(err, "Failed to Clean Up Charts of Disabled Repository", "Name", repository.key())
+			return reconcile.Result{}, err
+		}
+
+		setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncSynced, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncDisabledReason, "Repository is disabled")
+
+		err = r.GetClient().Status().Update(ctx, helmChartRepositorySync)
+
+		if err != nil {
+			r.Log.Error(err, "Failed to Update Repository Sync Status", "Name", repository.key())
+			return reconcile.Result{}, err
+		}
+	}
+
+	return ctrl.Result{Requeue: true, RequeueAfter: r.schedule.Next(repository.key(), options.SyncInterval)}, nil
+}
+
+// mapToHelmChart returns the chart created for the versions of a chart within the repository along with the
+// resources representing each version
+func (r *RepositoryReconciler) mapToHelmChart(repository *chartRepository, chartName string, versions repo.ChartVersions, selection *chartSelection) (types.HelmChartObject, []types.HelmChartVersionObject, error) {
+
+	entry := &types.HelmChartEntry{
+		Name:                  chartName,
+		RepositoryName:        repository.name(),
+		RepositoryDisplayName: repository.displayName,
+		Namespace:             repository.namespace(),
+		ChartVersions:         versions,
+		ServerVersion:         r.ServerVersion,
+		VersionRetention:      selection.retention,
+		HidePrereleases:       selection.visibility.HidePrereleases,
+		HideDeprecated:        selection.visibility.HideDeprecatedVersions,
+		Credentials:           selection.credentials,
+	}
+
+	helmChartVersionSpecs, err := utils.MapToHelmChartVersions(entry)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	helmChartVersions := []types.HelmChartVersionObject{}
+
+	// The charts are returned explicitly to avoid a typed nil within the interface
+	if repository.namespace() != "" {
+		for i := range helmChartVersionSpecs {
+			projectHelmChartVersion, err := utils.MapToProjectHelmChartVersion(entry, &helmChartVersionSpecs[i])
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			helmChartVersions = append(helmChartVersions, projectHelmChartVersion)
+		}
+
+		projectHelmChart, err := utils.MapToProjectHelmChart(entry)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return projectHelmChart, helmChartVersions, nil
+	}
+
+	for i := range helmChartVersionSpecs {
+		helmChartVersion, err := utils.MapToHelmChartVersion(entry, &helmChartVersionSpecs[i])
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		helmChartVersions = append(helmChartVersions, helmChartVersion)
+	}
+
+	helmChart, err := utils.MapToHelmChart(entry)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return helmChart, helmChartVersions, nil
+}
+
+// isModifiedOutsideOperator filters events to the charts and chart versions that were modified or deleted outside of
+// the operator. The operator annotates the resources it writes with the hash of their spec, so updates retaining a
+// matching hash were made by the operator. Creations are ignored as only the operator creates these resources
+func (r *RepositoryReconciler) isModifiedOutsideOperator() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() && !hasMatchingSpecHash(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			if r.ownDeletions.Take(e.Object.GetUID()) {
+				return false
+			}
+
+			// The versions of deleted charts are removed by the garbage collector, while the deletion of the chart
+			// itself determines whether the chart was modified
+			if _, isVersion := e.Object.(types.HelmChartVersionObject); isVersion {
+				return !r.isOwnerDeleted(e.Object)
+			}
+
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// hasMatchingSpecHash determines whether the spec of the chart or chart version matches its spec hash annotation
+func hasMatchingSpecHash(obj client.Object) bool {
+
+	var specHash string
+	var err error
+
+	switch managedObject := obj.(type) {
+	case types.HelmChartObject:
+		specHash, err = utils.HashHelmChartSpec(managedObject.GetHelmChartSpec())
+	case types.HelmChartVersionObject:
+		specHash, err = utils.HashHelmChartVersionSpec(managedObject.GetHelmChartVersionSpec())
+	default:
+		return false
+	}
+
+	return err == nil && specHash == obj.GetAnnotations()[utils.SpecHashAnnotationKey]
+}
+
+// isOwnerDeleted determines whether the chart owning a chart version was deleted or is being deleted
+func (r *RepositoryReconciler) isOwnerDeleted(obj client.Object) bool {
+
+	owner := metav1.GetControllerOf(obj)
+
+	if owner == nil {
+		return false
+	}
+
+	if r.ownDeletions.Contains(owner.UID) {
+		return true
+	}
+
+	var helmChart client.Object = &redhatcopv1alpha1.HelmChart{}
+
+	if obj.GetNamespace() != "" {
+		helmChart = &redhatcopv1alpha1.ProjectHelmChart{}
+	}
+
+	err := r.GetClient().Get(context.Background(), k8stypes.NamespacedName{Namespace: obj.GetNamespace(), Name: owner.Name}, helmChart)
+
+	if apierrors.IsNotFound(err) {
+		return true
+	}
+
+	// A chart with a different UID replaced the deleted owner
+	return err == nil && (helmChart.GetUID() != owner.UID || util.IsBeingDeleted(helmChart))
+}
+
+// deleteOwnResource deletes a chart or chart version, recording the deletion so it does not mark the chart as modified
+func (r *RepositoryReconciler) deleteOwnResource(ctx context.Context, obj client.Object) error {
+
+	r.ownDeletions.Add(obj.GetUID())
+
+	err := r.GetClient().Delete(ctx, obj)
+
+	// No delete event is received for objects that no longer exist
+	if err != nil {
+		r.ownDeletions.Take(obj.GetUID())
+
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// findRepositoryForHelmChart marks the chart as modified and returns a request for the repository it belongs to
+func (r *RepositoryReconciler) findRepositoryForHelmChart(obj client.Object) []reconcile.Request {
+
+	repositoryName, found := obj.GetLabels()[utils.RepositoryLabelKey]
+
+	if !found || !strings.HasPrefix(obj.GetName(), repositoryName+".") {
+		return nil
+	}
+
+	r.dirtyCharts.Add(repositoryKey(obj.GetNamespace(), repositoryName), strings.TrimPrefix(obj.GetName(), repositoryName+"."))
+
+	return []reconcile.Request{{NamespacedName: k8stypes.NamespacedName{Namespace: obj.GetNamespace(), Name: repositoryName}}}
+}
+
+// findRepositoryForHelmChartVersion marks the chart of a modified chart version for synchronization and returns a
+// request for the repository it belongs to
+func (r *RepositoryReconciler) findRepositoryForHelmChartVersion(obj client.Object) []reconcile.Request {
+
+	repositoryName, found := obj.GetLabels()[utils.RepositoryLabelKey]
+	chartName, chartFound := obj.GetLabels()[utils.ChartLabelKey]
+
+	if !found || !chartFound {
+		return nil
+	}
+
+	r.dirtyCharts.Add(repositoryKey(obj.GetNamespace(), repositoryName), chartName)
+
+	return []reconcile.Request{{NamespacedName: k8stypes.NamespacedName{Namespace: obj.GetNamespace(), Name: repositoryName}}}
+}
+
+// syncRepository retrieves the index of the repository and synchronizes the charts it contains. The modified charts
+// are synchronized even when the index has not been modified
+func (r *RepositoryReconciler) syncRepository(ctx context.Context, repository *chartRepository, helmChartRepositorySync types.HelmChartRepositorySyncObject, options *repositoryOptions, dirtyChartNames []string) error {
+
+	repositoryURL, err := url.Parse(options.URL)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("Unable to parse repository URL %v", options.URL))
+		setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncConfigurationInvalidReason, err.Error())
+		r.recordSyncFailure(repository, ConfigurationInvalidEventReason, err)
+		return err
+	}
+
+	// The URL of project repositories is not validated by the annotations, so local index files are rejected here
+	if repositoryURL.Scheme == fileScheme && !repository.allowLocalFiles {
+		err = fmt.Errorf("Local index files are not permitted for repository %s", repository.key())
+		setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncConfigurationInvalidReason, err.Error())
+		r.recordSyncFailure(repository, ConfigurationInvalidEventReason, err)
+		return err
+	}
+
+	chartFilter, err := r.getChartFilter(ctx, repository)
+	if err != nil {
+		r.recordSyncFailure(repository, ConfigurationInvalidEventReason, err)
+		return err
+	}
+
+	var cacheEntry *indexCacheEntry
+	var modified bool
+	var chartCredentials *types.ChartCredentials
+
+	if isLocalURLScheme(repositoryURL.Scheme) {
+		cacheEntry, modified, err = r.fetchLocalIndexFile(ctx, repository, repositoryURL)
+	} else {
+		var httpClient *http.Client
+		httpClient, err = r.getHttpClient(ctx, repository)
+		if err != nil {
+			setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncConfigurationInvalidReason, err.Error())
+			r.recordSyncFailure(repository, TLSFailedEventReason, err)
+			return err
+		}
+
+		// The annotation takes precedence over the basic authentication configuration of project repositories
+		authSecret := options.AuthSecret
+		if authSecret == "" {
+			authSecret = repository.basicAuthConfigName
+		}
+
+		var credentials *repositoryCredentials
+		if authSecret != "" {
+			credentials, err = r.getRepositoryCredentials(ctx, repository, authSecret)
+			if err != nil {
+				setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncConfigurationInvalidReason, err.Error())
+				r.recordSyncFailure(repository, AuthenticationFailedEventReason, err)
+				return err
+			}
+		}
+
+		if credentials != nil {
+			chartCredentials = &types.ChartCredentials{Host: repositoryURL.Host, PassCredentialsAll: options.PassCredentials}
+		}
+
+		cacheEntry, modified, err = r.fetchRemoteIndexFile(ctx, repository, httpClient, credentials, repositoryURL, options)
+	}
+
+	if err != nil {
+		var parseErr *indexParseError
+		if errors.As(err, &parseErr) {
+			setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionTrue, redhatcopv1alpha1.HelmChartRepositorySyncIndexRetrievedReason, "")
+			setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncIndexParsed, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncIndexInvalidReason, err.Error())
+			r.recordSyncFailure(repository, IndexInvalidEventReason, err)
+		} else {
+			setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionFalse, redhatcopv1alpha1.HelmChartRepositorySyncFetchFailedReason, err.Error())
+			r.recordSyncFailure(repository, fetchFailedEventReason(err), err)
+		}
+		return err
+	}
+
+	setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncReachable, metav1.ConditionTrue, redhatcopv1alpha1.HelmChartRepositorySyncIndexRetrievedReason, "")
+	setSyncCondition(helmChartRepositorySync, redhatcopv1alpha1.HelmChartRepositorySyncIndexParsed, metav1.ConditionTrue, redhatcopv1alpha1.HelmChartRepositorySyncIndexValidReason, "")
+
+	// The settings are applied to a copy of the entry, which only replaces the cached entry once the charts were
+	// synchronized, so changed settings are detected again after a failed synchronization
+	synchronizedEntry := *cacheEntry
+	cacheEntry = &synchronizedEntry
+
+	now := clock.Now()
+	cacheEntry.LastSync = now
+	indexFile := cacheEntry.IndexFile
+
+	// All charts are synchronized again when the settings selecting the charts and versions or the credentials used to
+	// download them changed. A maximum age excludes versions as they age, so the charts are also synchronized again
+	// once a version exceeded it
+	if !modified && (cacheEntry.Retention != options.Retention || cacheEntry.versionsExpired(now) || cacheEntry.Filter.Hash() != chartFilter.Hash() || cacheEntry.Visibility != options.Visibility || !reflect.DeepEqual(cacheEntry.Credentials, chartCredentials)) {
+		r.Log.Info("Applying Chart Selection", "Name", repository.key())
+		modified = true
+	}
+
+	cacheEntry.Retention = options.Retention
+	cacheEntry.Filter = chartFilter
+	cacheEntry.Visibility = options.Visibility
+	cacheEntry.Credentials = chartCredentials
+
+	if !modified {
+
+		// The charts were verified against the unmodified index, so their last checked timestamp is kept current
+		err = r.refreshLastChecked(ctx, repository)
+
+		if err != nil {
+			r.Log.Error(err, "Failed to Refresh Chart Status", "Name", repository.key())
+			return err
+		}
+
+		if len(cacheEntry.FailedCharts) == 0 && len(dirtyChartNames) == 0 {
+			r.Log.Info("Repository Index Not Modified", "Name", repository.key())
+			r.indexCache.Set(repository.key(), cacheEntry)
+			return nil
+		}
+
+		// Only retry the charts that previously failed to synchronize and restore the charts that were modified
+		r.Log.Info("Retrying Failed Charts", "Name", repository.key(), "Count", len(cacheEntry.FailedCharts), "Modified", len(dirtyChartNames))
+
+		chartNameSet := map[string]struct{}{}
+		for _, chartName := range dirtyChartNames {
+			chartNameSet[chartName] = struct{}{}
+		}
+
+		for chartName := range cacheEntry.FailedCharts {
+			chartNameSet[chartName] = struct{}{}
+		}
+
+		chartNames := []string{}
+		for chartName := range chartNameSet {
+			chartNames = append(chartNames, chartName)
+		}
+
+		appliedCharts, failedCharts := r.applyHelmCharts(ctx, repository, indexFile, chartNames, cacheEntry.chartSelection(now))
+
+		// Modified charts that synchronized previously are already counted
+		for chartName, versionCount := range appliedCharts {
+			if _, previouslyFailed := cacheEntry.FailedCharts[chartName]; previouslyFailed {
+				helmChartRepositorySync.GetSyncStatus().ChartCount++
+				helmChartRepositorySync.GetSyncStatus().VersionCount += versionCount
+			}
+		}
+
+		if len(cacheEntry.FailedCharts) > 0 {
+			cacheEntry.RetryCount++
+		}
+
+		cacheEntry.FailedCharts = failedCharts
+		r.indexCache.Set(repository.key(), cacheEntry)
+
+		return r.newChartSyncError(helmChartRepositorySync, failedCharts, cacheEntry.RetryCount, options.SyncInterval)
+	}
+
+	chartNames := []string{}
+	for chartName := range indexFile.Entries {
+		chartNames = append(chartNames, chartName)
+	}
+
+	appliedCharts, failedCharts := r.applyHelmCharts(ctx, repository, indexFile, chartNames, cacheEntry.chartSelection(now))
+	cacheEntry.SelectionApplied = now
+
+	// Charts that failed to synchronize are retained until they can be synchronized
+	indexedCharts := map[string]struct{}{}
+	versionCount := 0
+
+	for chartName, chartVersionCount := range appliedCharts {
+		indexedCharts[utils.HelmChartName(repository.name(), chartName)] = struct{}{}
+		versionCount += chartVersionCount
+	}
+
+	for chartName := range failedCharts {
+		indexedCharts[utils.HelmChartName(repository.name(), chartName)] = struct{}{}
+	}
+
+	err = r.cleanupOrphanedCharts(ctx, repository, indexFile, indexedCharts)
+
+	if err != nil {
+		r.Log.Error(err, "Failed to Clean Up Orphaned Charts", "Name", repository.key())
+		return err
+	}
+
+	helmChartRepositorySync.GetSyncStatus().ChartCount = len(appliedCharts)
+	helmChartRepositorySync.GetSyncStatus().VersionCount = versionCount
+
+	if !indexFile.Generated.IsZero() {
+		helmChartRepositorySync.GetSyncStatus().IndexGeneratedTimestamp = &metav1.Time{Time: indexFile.Generated}
+	}
+
+	cacheEntry.FailedCharts = failedCharts
+	cacheEntry.RetryCount = 0
+	r.indexCache.Set(repository.key(), cacheEntry)
+
+	r.GetRecorder().Event(repository.object, corev1.EventTypeNormal, SynchronizedEventReason, fmt.Sprintf("Synchronized %d charts containing %d versions", len(appliedCharts), versionCount))
+
+	return r.newChartSyncError(helmChartRepositorySync, failedCharts, cacheEntry.RetryCount, options.SyncInterval)
+}
+
+// chartSelection contains the settings selecting the charts and versions of an index that are synchronized, along with
+// the credentials used to download them
+type chartSelection struct {
+	retention   *types.VersionRetention
+	filter      *filter.ChartFilter
+	visibility  versionVisibilityOptions
+	credentials *types.ChartCredentials
+}
+
+// applyHelmCharts maps and applies the given charts of the index. Failures of individual charts do not prevent the
+// remaining charts from being applied. The number of versions of each applied chart and the error of each failed chart
+// are returned keyed by chart name
+func (r *RepositoryReconciler) applyHelmCharts(ctx context.Context, repository *chartRepository, indexFile *repo.IndexFile, chartNames []string, selection *chartSelection) (map[string]int, map[string]error) {
+
+	appliedCharts := map[string]int{}
+	failedCharts := map[string]error{}
+
+	sort.Strings(chartNames)
+
+	for _, chartName := range chartNames {
+
+		versions, found := indexFile.Entries[chartName]
+
+		if !found {
+			continue
+		}
+
+		// Deprecation is determined using all versions of the index, as Helm considers a chart deprecated when its
+		// latest version is deprecated
+		deprecated := utils.IsChartDeprecated(versions)
+
+		// Hidden charts and charts without versions selected by the filters are handled like charts that were removed
+		// from the index
+		if deprecated && selection.visibility.HideDeprecatedCharts {
+			continue
+		}
+
+		if selection.filter != nil {
+			versions = selection.filter.Filter(chartName, versions)
+
+			if len(versions) == 0 {
+				continue
+			}
+		}
+
+		helmChart, helmChartVersions, err := r.mapToHelmChart(repository, chartName, versions, selection)
+
+		if err != nil {
+			r.Log.Error(err, "Failed to map to Helm Chart", "Chart", chartName)
+			failedCharts[chartName] = err
+			continue
+		}
+
+		err = r.applyHelmChart(ctx, repository, helmChart, helmChartVersions, deprecated)
+
+		if err != nil {
+			r.Log.Error(err, "Failed to Update Chart", "Name", helmChart.GetName())
+			failedCharts[chartName] = err
+			continue
+		}
+
+		appliedCharts[chartName] = len(helmChartVersions)
+	}
+
+	return appliedCharts, failedCharts
+}
+
+// newChartSyncError records the charts that failed to synchronize in the sync status and returns an error
+// describing the failures along with the delay before they should be retried
+func (r *RepositoryReconciler) newChartSyncError(helmChartRepositorySync types.HelmChartRepositorySyncObject, failedCharts map[string]error, retryCount int, maxRetryAfter time.Duration) error {
+
+	helmChartRepositorySync.GetSyncStatus().FailedCharts = nil
+	helmChartRepositorySync.GetSyncStatus().OmittedFailedCharts = 0
+
+	if len(failedCharts) == 0 {
+		return nil
+	}
+
+	chartNames := []string{}
+	for chartName := range failedCharts {
+		chartNames = append(chartNames, chartName)
+	}
+	sort.Strings(chartNames)
+
+	errs := []error{}
+
+	for _, chartName := range chartNames {
+		if len(helmChartRepositorySync.GetSyncStatus().FailedCharts) < maxStatusChartFailures {
+			helmChartRepositorySync.GetSyncStatus().FailedCharts = append(helmChartRepositorySync.GetSyncStatus().FailedCharts, redhatcopv1alpha1.HelmChartSyncFailure{
+				Name:    chartName,
+				Message: truncateString(failedCharts[chartName].Error(), maxChartFailureMessageLength),
+			})
+		} else {
+			helmChartRepositorySync.GetSyncStatus().OmittedFailedCharts++
+		}
+
+		if len(errs) < maxReportedChartFailures {
+			errs = append(errs, fmt.Errorf("chart %s: %v", chartName, failedCharts[chartName]))
+		}
+	}
+
+	retryAfter := chartRetryBaseDelay * time.Duration(1<<uint(retryCount))
+	if retryCount >= chartRetryMaxDoublings || retryAfter > maxRetryAfter {
+		retryAfter = maxRetryAfter
+	}
+
+	return &chartSyncError{
+		err:        utilerrors.NewAggregate(errs),
+		count:      len(chartNames),
+		retryAfter: retryAfter,
+	}
+}
+
+// applyHelmChart creates or updates the chart unless the content of the existing chart matches, followed by the
+// resources representing its versions. The Deprecated condition of the chart reflects whether the chart is deprecated
+func (r *RepositoryReconciler) applyHelmChart(ctx context.Context, repository *chartRepository, helmChart types.HelmChartObject, helmChartVersions []types.HelmChartVersionObject, deprecated bool) error {
+
+	existingHelmChart := repository.newHelmChart()
+	err := r.GetClient().Get(ctx, k8stypes.NamespacedName{Namespace: helmChart.GetNamespace(), Name: helmChart.GetName()}, existingHelmChart)
+
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	now := &metav1.Time{Time: clock.Now()}
+
+	found := err == nil
+	updated := false
+
+	// Versions are owned by the chart, so the chart is applied first
+	if !found || !isHelmChartCurrent(existingHelmChart, helmChart) {
+
+		r.Log.Info("Updating Chart", "Name", helmChart.GetName(), "Namespace", helmChart.GetNamespace())
+
+		err = r.CreateOrUpdateResource(ctx, repository.object, repository.namespace(), helmChart)
+
+		if err != nil {
+			return err
+		}
+
+		updated = true
+	} else {
+		helmChart = existingHelmChart
+	}
+
+	existingVersions, versionsChanged, err := r.applyHelmChartVersions(ctx, repository, helmChart, helmChartVersions)
+
+	if err != nil {
+		return err
+	}
+
+	versions := []string{}
+	helmChartVersionSpecs := []redhatcopv1alpha1.HelmChartVersionSpec{}
+
+	for _, helmChartVersion := range helmChartVersions {
+		versions = append(versions, helmChartVersion.GetHelmChartVersionSpec().Version)
+		helmChartVersionSpecs = append(helmChartVersionSpecs, *helmChartVersion.GetHelmChartVersionSpec())
+	}
+
+	// Charts created before versions were represented as separate resources contain their versions in the spec
+	if len(existingVersions) == 0 && found {
+		for _, helmChartVersion := range existingHelmChart.GetHelmChartSpec().Versions {
+			existingVersions = append(existingVersions, helmChartVersion.Version)
+		}
+	}
+
+	// Events are only recorded for existing charts to avoid an event for every version of a new repository
+	if found {
+		r.recordVersionEvents(helmChart, existingVersions, versions)
+	}
+
+	status := helmChart.GetHelmChartStatus()
+
+	// The summary is also set on charts created before the summary was introduced
+	summaryChanged := utils.SetHelmChartSummary(status, helmChartVersionSpecs)
+	deprecatedChanged := setHelmChartDeprecatedCondition(helmChart, existingHelmChart.GetConditions(), deprecated)
+
+	// The chart is present in the index, so it is no longer orphaned
+	orphanedChanged := clearHelmChartCondition(helmChart, redhatcopv1alpha1.HelmChartOrphaned, redhatcopv1alpha1.HelmChartPresentInIndexReason,
+		fmt.Sprintf("Chart %s is present in the index of repository %s", helmChart.GetHelmChartSpec().Name, repository.name()))
+
+	// Charts are only applied while the repository is enabled
+	disabledChanged := clearHelmChartCondition(helmChart, redhatcopv1alpha1.HelmChartRepositoryDisabled, redhatcopv1alpha1.HelmChartRepositoryEnabledReason,
+		fmt.Sprintf("Repository %s has been enabled", repository.name()))
+
+	if !updated && !versionsChanged && !summaryChanged && !deprecatedChanged && !orphanedChanged && !disabledChanged && status.LastCheckedTimestamp != nil && clock.Since(status.LastCheckedTimestamp.Time) < lastCheckedRefreshPeriod {
+		return nil
+	}
+
+	if updated || versionsChanged {
+		status.LastUpdateTimestamp = now
+	}
+
+	status.LastCheckedTimestamp = now
+
+	return r.GetClient().Status().Update(ctx, helmChart)
+}
+
+// applyHelmChartVersions creates or updates the resources representing the versions of the chart and deletes those
+// of versions that are no longer present. The versions that existed beforehand are returned along with whether any
+// version changed
+func (r *RepositoryReconciler) applyHelmChartVersions(ctx context.Context, repository *chartRepository, helmChart types.HelmChartObject, helmChartVersions []types.HelmChartVersionObject) ([]string, bool, error) {
+
+	existingHelmChartVersions, err := r.listHelmChartVersions(ctx, repository, helmChart.GetHelmChartSpec().Name)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	existingVersions := []string{}
+	existingByName := map[string]types.HelmChartVersionObject{}
+
+	for _, existingHelmChartVersion := range existingHelmChartVersions {
+		existingVersions = append(existingVersions, existingHelmChartVersion.GetHelmChartVersionSpec().Version)
+		existingByName[existingHelmChartVersion.GetName()] = existingHelmChartVersion
+	}
+
+	changed := false
+
+	for _, helmChartVersion := range helmChartVersions {
+
+		existingHelmChartVersion, found := existingByName[helmChartVersion.GetName()]
+		delete(existingByName, helmChartVersion.GetName())
+
+		if found && isHelmChartVersionCurrent(existingHelmChartVersion, helmChartVersion) {
+			continue
+		}
+
+		err = r.CreateOrUpdateResource(ctx, helmChart, repository.namespace(), helmChartVersion)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		changed = true
+	}
+
+	for _, existingHelmChartVersion := range existingByName {
+		r.Log.Info("Deleting Chart Version", "Name", existingHelmChartVersion.GetName(), "Namespace", existingHelmChartVersion.GetNamespace())
+
+		err = r.deleteOwnResource(ctx, existingHelmChartVersion)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		changed = true
+	}
+
+	return existingVersions, changed, nil
+}
+
+// isHelmChartVersionCurrent determines whether the existing chart version matches the desired chart version
+func isHelmChartVersionCurrent(existingHelmChartVersion types.HelmChartVersionObject, helmChartVersion types.HelmChartVersionObject) bool {
+
+	if existingHelmChartVersion.GetAnnotations()[utils.SpecHashAnnotationKey] != helmChartVersion.GetAnnotations()[utils.SpecHashAnnotationKey] {
+		return false
+	}
+
+	// The content is hashed again to detect modifications made outside of the operator
+	existingSpecHash, err := utils.HashHelmChartVersionSpec(existingHelmChartVersion.GetHelmChartVersionSpec())
+
+	if err != nil || existingSpecHash != helmChartVersion.GetAnnotations()[utils.SpecHashAnnotationKey] {
+		return false
+	}
+
+	return reflect.DeepEqual(existingHelmChartVersion.GetLabels(), helmChartVersion.GetLabels())
+}
+
+// isHelmChartCurrent determines whether the existing chart matches the desired chart
+func isHelmChartCurrent(existingHelmChart types.HelmChartObject, helmChart types.HelmChartObject) bool {
+
+	if existingHelmChart.GetAnnotations()[utils.SpecHashAnnotationKey] != helmChart.GetAnnotations()[utils.SpecHashAnnotationKey] {
+		return false
+	}
+
+	// The content is hashed again to detect modifications made outside of the operator
+	existingSpecHash, err := utils.HashHelmChartSpec(existingHelmChart.GetHelmChartSpec())
+
+	if err != nil || existingSpecHash != helmChart.GetAnnotations()[utils.SpecHashAnnotationKey] {
+		return false
+	}
+
+	// The Orphaned and RepositoryDisabled conditions are cleared by a status update when the chart is applied, so
+	// they do not require the chart itself to be updated
+	return reflect.DeepEqual(existingHelmChart.GetLabels(), helmChart.GetLabels())
+}
+
+// fetchRemoteIndexFile retrieves the index of a repository served over HTTP or stored in an OCI registry
+func (r *RepositoryReconciler) fetchRemoteIndexFile(ctx context.Context, repository *chartRepository, httpClient *http.Client, credentials *repositoryCredentials, repositoryURL *url.URL, options *repositoryOptions) (*indexCacheEntry, bool, error) {
+
+	if repositoryURL.Scheme == oci.Scheme {
+		return r.fetchOCIIndexFile(ctx, repository, httpClient, credentials, options)
+	}
+
+	if credentials != nil {
+		httpClient.Transport = &credentialsTransport{
+			base:               httpClient.Transport,
+			credentials:        credentials,
+			host:               repositoryURL.Host,
+			passCredentialsAll: options.PassCredentials,
+		}
+	}
+
+	indexURL := repositoryURL.String()
+	if !strings.HasSuffix(indexURL, "/index.yaml") {
+		indexURL += "/index.yaml"
+	}
+
+	return r.fetchIndexFile(ctx, repository, httpClient, indexURL)
+}
+
+// fetchIndexFile retrieves and parses the index of the repository. When the index has previously been synchronized,
+// a conditional request is made and the cached index is returned along with modified set to false if the server
+// reports the index has not been modified since
+func (r *RepositoryReconciler) fetchIndexFile(ctx context.Context, repository *chartRepository, httpClient *http.Client, indexURL string) (*indexCacheEntry, bool, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cacheEntry, cached := r.indexCache.Get(repository.key())
+
+	// Cached entries are only valid for the same location and repository configuration
+	if cached && (cacheEntry.URL != indexURL || cacheEntry.Generation != repository.object.GetGeneration()) {
+		cached = false
+	}
+
+	if cached {
+		if cacheEntry.ETag != "" {
+			req.Header.Set("If-None-Match", cacheEntry.ETag)
+		}
+		if cacheEntry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cacheEntry.LastModified)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		return cacheEntry, false, nil
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, false, &httpStatusError{url: indexURL, statusCode: resp.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(r.MaxIndexSize)+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(body) > r.MaxIndexSize {
+		return nil, false, fmt.Errorf("Index %s exceeds the maximum size of %d bytes", indexURL, r.MaxIndexSize)
+	}
+
+	indexFile, err := r.parseIndexFile(repository, body, indexURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &indexCacheEntry{
+		URL:          indexURL,
+		Generation:   repository.object.GetGeneration(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		IndexFile:    indexFile,
+		Size:         len(body),
+	}, true, nil
+}
+
+// parseIndexFile parses the content of a repository index. Chart URLs are resolved relative to indexURL unless empty
+func (r *RepositoryReconciler) parseIndexFile(repository *chartRepository, body []byte, indexURL string) (*repo.IndexFile, error) {
+
+	var indexFile repo.IndexFile
+
+	err := yaml.Unmarshal(body, &indexFile)
+	if err != nil {
+		return nil, &indexParseError{err: err}
+	}
+
+	if indexURL != "" {
+		for _, chartVersions := range indexFile.Entries {
+			for _, chartVersion := range chartVersions {
+				for i, url := range chartVersion.URLs {
+					chartVersion.URLs[i], err = repo.ResolveReferenceURL(indexURL, url)
+					if err != nil {
+						r.Log.Error(err, "Error resolving chart url", repository.key())
+					}
+				}
+			}
+		}
+	}
+
+	// Sort Entries
+	indexFile.SortEntries()
+
+	return &indexFile, nil
+}
+
+// cleanupOrphanedCharts handles charts belonging to the repository that are not present in indexedCharts
+// according to the configured OrphanedChartPolicy. The entries of the index distinguish charts removed from the index
+// from charts that were excluded from the synchronization
+func (r *RepositoryReconciler) cleanupOrphanedCharts(ctx context.Context, repository *chartRepository, indexFile *repo.IndexFile, indexedCharts map[string]struct{}) error {
+
+	helmCharts, err := r.listHelmCharts(ctx, repository)
+
+	if err != nil {
+		return err
+	}
+
+	for _, helmChart := range helmCharts {
+
+		if _, found := indexedCharts[helmChart.GetName()]; found {
+			continue
+		}
+
+		if r.OrphanedChartPolicy == RetainChartCleanupPolicy {
+			r.Log.Info("Marking Orphaned Chart", "Name", helmChart.GetName(), "Namespace", helmChart.GetNamespace())
+
+			chartName := helmChart.GetHelmChartSpec().Name
+
+			// Charts that are still present in the index were excluded by the filters or hidden as deprecated
+			if _, found := indexFile.Entries[chartName]; found {
+				err = r.setHelmChartCondition(ctx, helmChart, redhatcopv1alpha1.HelmChartOrphaned, redhatcopv1alpha1.HelmChartExcludedReason,
+					fmt.Sprintf("Chart %s of repository %s is excluded by chart filters or hidden as deprecated", chartName, repository.name()))
+			} else {
+				err = r.setHelmChartCondition(ctx, helmChart, redhatcopv1alpha1.HelmChartOrphaned, redhatcopv1alpha1.HelmChartOrphanedReason,
+					fmt.Sprintf("Chart %s is no longer present in the index of repository %s", chartName, repository.name()))
+			}
+		} else {
+			r.Log.Info("Deleting Orphaned Chart", "Name", helmChart.GetName(), "Namespace", helmChart.GetNamespace())
+
+			err = r.deleteOwnResource(ctx, helmChart)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refreshLastChecked updates the last checked timestamp of the charts of the repository that exceeded the refresh
+// period. Orphaned charts are no longer verified against the index, so their timestamp is retained
+func (r *RepositoryReconciler) refreshLastChecked(ctx context.Context, repository *chartRepository) error {
+
+	helmCharts, err := r.listHelmCharts(ctx, repository)
+
+	if err != nil {
+		return err
+	}
+
+	now := clock.Now()
+
+	for _, helmChart := range helmCharts {
+		status := helmChart.GetHelmChartStatus()
+
+		if status.LastCheckedTimestamp != nil && now.Sub(status.LastCheckedTimestamp.Time) < lastCheckedRefreshPeriod {
+			continue
+		}
+
+		if condition, found := apis.GetCondition(redhatcopv1alpha1.HelmChartOrphaned, helmChart.GetConditions()); found && condition.Status == metav1.ConditionTrue {
+			continue
+		}
+
+		status.LastCheckedTimestamp = &metav1.Time{Time: now}
+
+		err = r.GetClient().Status().Update(ctx, helmChart)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flagDisabledCharts marks every chart belonging to the repository as originating from a disabled repository
+func (r *RepositoryReconciler) flagDisabledCharts(ctx context.Context, repository *chartRepository) error {
+
+	helmCharts, err := r.listHelmCharts(ctx, repository)
+
+	if err != nil {
+		return err
+	}
+
+	for _, helmChart := range helmCharts {
+		err = r.setHelmChartCondition(ctx, helmChart, redhatcopv1alpha1.HelmChartRepositoryDisabled, redhatcopv1alpha1.HelmChartRepositoryDisabledReason,
+			fmt.Sprintf("Repository %s has been disabled", repository.name()))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteHelmCharts removes every chart belonging to the repository
+func (r *RepositoryReconciler) deleteHelmCharts(ctx context.Context, repository *chartRepository) error {
+
+	helmCharts, err := r.listHelmCharts(ctx, repository)
+
+	if err != nil {
+		return err
+	}
+
+	for _, helmChart := range helmCharts {
+		r.Log.Info("Deleting Chart", "Name", helmChart.GetName(), "Namespace", helmChart.GetNamespace())
+
+		err = r.deleteOwnResource(ctx, helmChart)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listHelmCharts returns the charts belonging to the repository
+func (r *RepositoryReconciler) listHelmCharts(ctx context.Context, repository *chartRepository) ([]types.HelmChartObject, error) {
+
+	helmCharts := []types.HelmChartObject{}
+
+	if repository.namespace() != "" {
+		projectHelmChartList := &redhatcopv1alpha1.ProjectHelmChartList{}
+		err := r.GetClient().List(ctx, projectHelmChartList, client.InNamespace(repository.namespace()), client.MatchingLabels{utils.RepositoryLabelKey: repository.name()})
+
+		for i := range projectHelmChartList.Items {
+			helmCharts = append(helmCharts, &projectHelmChartList.Items[i])
+		}
+
+		return helmCharts, err
+	}
+
+	helmChartList := &redhatcopv1alpha1.HelmChartList{}
+	err := r.GetClient().List(ctx, helmChartList, client.MatchingLabels{utils.RepositoryLabelKey: repository.name()})
+
+	for i := range helmChartList.Items {
+		helmCharts = append(helmCharts, &helmChartList.Items[i])
+	}
+
+	return helmCharts, err
+}
+
+// listHelmChartVersions returns the versions belonging to a chart of the repository
+func (r *RepositoryReconciler) listHelmChartVersions(ctx context.Context, repository *chartRepository, chartName string) ([]types.HelmChartVersionObject, error) {
+
+	helmChartVersions := []types.HelmChartVersionObject{}
+	labels := client.MatchingLabels{utils.RepositoryLabelKey: repository.name(), utils.ChartLabelKey: chartName}
+
+	if repository.namespace() != "" {
+		projectHelmChartVersionList := &redhatcopv1alpha1.ProjectHelmChartVersionList{}
+		err := r.GetClient().List(ctx, projectHelmChartVersionList, client.InNamespace(repository.namespace()), labels)
+
+		for i := range projectHelmChartVersionList.Items {
+			helmChartVersions = append(helmChartVersions, &projectHelmChartVersionList.Items[i])
+		}
+
+		return helmChartVersions, err
+	}
+
+	helmChartVersionList := &redhatcopv1alpha1.HelmChartVersionList{}
+	err := r.GetClient().List(ctx, helmChartVersionList, labels)
+
+	for i := range helmChartVersionList.Items {
+		helmChartVersions = append(helmChartVersions, &helmChartVersionList.Items[i])
+	}
+
+	return helmChartVersions, err
+}
+
+// setHelmChartCondition sets a condition with a status of true on the chart unless it is already present with the
+// same reason
+func (r *RepositoryReconciler) setHelmChartCondition(ctx context.Context, helmChart types.HelmChartObject, conditionType string, reason string, message string) error {
+
+	lastTransitionTime := metav1.Now()
+
+	if condition, found := apis.GetCondition(conditionType, helmChart.GetConditions()); found && condition.Status == metav1.ConditionTrue {
+		if condition.Reason == reason {
+			return nil
+		}
+
+		lastTransitionTime = condition.LastTransitionTime
+	}
+
+	helmChart.SetConditions(apis.AddOrReplaceCondition(metav1.Condition{
+		Type:               conditionType,
+		LastTransitionTime: lastTransitionTime,
+		ObservedGeneration: helmChart.GetGeneration(),
+		Message:            message,
+		Reason:             reason,
+		Status:             metav1.ConditionTrue,
+	}, helmChart.GetConditions()))
+
+	return r.GetClient().Status().Update(ctx, helmChart)
+}
+
+// clearHelmChartCondition sets a condition of the chart that is True to False and returns whether the condition changed.
+// Conditions that are absent are not added
+func clearHelmChartCondition(helmChart types.HelmChartObject, conditionType string, reason string, message string) bool {
+
+	if condition, found := apis.GetCondition(conditionType, helmChart.GetConditions()); !found || condition.Status != metav1.ConditionTrue {
+		return false
+	}
+
+	helmChart.SetConditions(apis.AddOrReplaceCondition(metav1.Condition{
+		Type:               conditionType,
+		LastTransitionTime: metav1.Now(),
+		ObservedGeneration: helmChart.GetGeneration(),
+		Message:            message,
+		Reason:             reason,
+		Status:             metav1.ConditionFalse,
+	}, helmChart.GetConditions()))
+
+	return true
+}
+
+// setHelmChartDeprecatedCondition sets the Deprecated condition of the chart and returns whether the condition
+// changed. The transition time of the previous conditions of the chart is retained when the status has not changed
+func setHelmChartDeprecatedCondition(helmChart types.HelmChartObject, previousConditions []metav1.Condition, deprecated bool) bool {
+
+	status := metav1.ConditionFalse
+	reason := redhatcopv1alpha1.HelmChartLatestVersionSupportedReason
+	message := ""
+
+	if deprecated {
+		status = metav1.ConditionTrue
+		reason = redhatcopv1alpha1.HelmChartLatestVersionDeprecatedReason
+		message = "The latest version of the chart is deprecated"
+	}
+
+	if condition, found := apis.GetCondition(redhatcopv1alpha1.HelmChartDeprecated, helmChart.GetConditions()); found && condition.Status == status && condition.Reason == reason {
+		return false
+	}
+
+	lastTransitionTime := metav1.Now()
+
+	if condition, found := apis.GetCondition(redhatcopv1alpha1.HelmChartDeprecated, previousConditions); found && condition.Status == status {
+		lastTransitionTime = condition.LastTransitionTime
+	}
+
+	helmChart.SetConditions(apis.AddOrReplaceCondition(metav1.Condition{
+		Type:               redhatcopv1alpha1.HelmChartDeprecated,
+		LastTransitionTime: lastTransitionTime,
+		ObservedGeneration: helmChart.GetGeneration(),
+		Message:            message,
+		Reason:             reason,
+		Status:             status,
+	}, helmChart.GetConditions()))
+
+	return true
+}
+
+func (r *RepositoryReconciler) getHttpClient(ctx context.Context, repository *chartRepository) (*http.Client, error) {
+
+	var err error
+
+	var rootCAs *x509.CertPool
+
+	if repository.caName != "" {
+		caName := repository.caName
+
+		configMap := &corev1.ConfigMap{}
+		err = repository.configReader.Get(ctx, k8stypes.NamespacedName{Name: caName, Namespace: repository.configNamespace}, configMap)
+
+		if err != nil {
+			r.Log.Error(err, "Unable to access ConfigMap from Config Namespace", "Name", caName, "Namespace", repository.configNamespace)
+		}
+		caCert, found := configMap.Data[caBundleKey]
+
+		if !found {
+			return nil, errors.New(fmt.Sprintf("Failed to find %s key in configmap %s", caBundleKey, caName))
+		}
+
+		if caCert != "" {
+			rootCAs = x509.NewCertPool()
+			if ok := rootCAs.AppendCertsFromPEM([]byte(caCert)); !ok {
+				return nil, errors.New("Failed to append caCert")
+			}
+		}
+	}
+
+	proxy, err := r.getClusterProxy(ctx, repository.configReader)
+	if err != nil {
+		return nil, err
+	}
+
+	if rootCAs == nil {
+		rootCAs, err = x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if proxy != nil && len(proxy.trustedCA) > 0 {
+		if ok := rootCAs.AppendCertsFromPEM(proxy.trustedCA); !ok {
+			return nil, errors.New("Failed to append proxy trusted CA")
+		}
+	}
+
+	tlsClientConfig := utils.SecureTLSConfig(&tls.Config{
+		RootCAs: rootCAs,
+	})
+
+	if repository.tlsClientConfigName != "" {
+
+		secretName := repository.tlsClientConfigName
+
+		secret := &corev1.Secret{}
+		err := repository.configReader.Get(ctx, k8stypes.NamespacedName{Name: secretName, Namespace: repository.configNamespace}, secret)
+
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Failed to GET secret %s reason %v", secretName, err))
+		}
+		tlsCertSecretKey := "tls.crt"
+		tlsCert, ok := secret.Data[tlsCertSecretKey]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Failed to find %s key in secret %s", tlsCertSecretKey, secretName))
+		}
+		tlsSecretKey := "tls.key"
+		tlsKey, ok := secret.Data[tlsSecretKey]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Failed to find %s key in secret %s", tlsSecretKey, secretName))
+		}
+		if tlsKey != nil && tlsCert != nil {
+			cert, err := tls.X509KeyPair(tlsCert, tlsKey)
+			if err != nil {
+				return nil, err
+			}
+			tlsClientConfig.Certificates = []tls.Certificate{cert}
+		}
+
+	}
+
+	connectTimeout := time.Second * time.Duration(r.ConnectTimeout)
+
+	tr := &http.Transport{
+		TLSClientConfig: tlsClientConfig,
+		Proxy:           proxy.proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: connectTimeout,
+	}
+
+	// The response timeout applies to each attempt, including reading the response body, rather than to the client as
+	// a whole, which would include the retries and the delays between them
+	return &http.Client{
+		Transport: &retryTransport{base: tr, maxRetries: r.FetchRetries, attemptTimeout: time.Second * time.Duration(r.ResponseTimeout)},
+	}, nil
+
+}
@@ -0,0 +1,1021 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	projecthelmv1beta1 "github.com/redhat-cop/helm-chart-repository-operator/pkg/apis/helm/v1beta1"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/utils"
+	"github.com/redhat-cop/operator-utils/pkg/util"
+	"github.com/redhat-cop/operator-utils/pkg/util/apis"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	kubeclock "k8s.io/apimachinery/pkg/util/clock"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// testManager provides the clients and scheme of the reconciler base using fake clients. The API reader is a separate
+// client, as it reads objects that are not available from the cache of the manager
+type testManager struct {
+	manager.Manager
+	client    client.Client
+	apiReader client.Reader
+	scheme    *runtime.Scheme
+}
+
+func (m *testManager) GetClient() client.Client {
+	return m.client
+}
+
+func (m *testManager) GetAPIReader() client.Reader {
+	return m.apiReader
+}
+
+func (m *testManager) GetScheme() *runtime.Scheme {
+	return m.scheme
+}
+
+// newTestScheme returns a scheme containing the types read and written by the reconcilers
+func newTestScheme(t *testing.T) *runtime.Scheme {
+
+	scheme := runtime.NewScheme()
+
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		redhatcopv1alpha1.AddToScheme,
+		helmv1beta1.AddToScheme,
+		projecthelmv1beta1.AddToScheme,
+		configv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return scheme
+}
+
+// newTestReconciler returns a reconciler using a fake client containing the objects and a recorder retaining the
+// recorded events
+func newTestReconciler(t *testing.T, objs ...client.Object) (*RepositoryReconciler, *record.FakeRecorder) {
+	return newTestReconcilerWithAPIReader(t, nil, objs...)
+}
+
+// newTestReconcilerWithAPIReader returns a reconciler whose API reader only contains the API reader objects, which are
+// not available from its client. The client also serves as the API reader when no API reader objects are given
+func newTestReconcilerWithAPIReader(t *testing.T, apiReaderObjs []client.Object, objs ...client.Object) (*RepositoryReconciler, *record.FakeRecorder) {
+
+	scheme := newTestScheme(t)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	recorder := record.NewFakeRecorder(100)
+
+	var apiReader client.Reader = fakeClient
+	if apiReaderObjs != nil {
+		apiReader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(apiReaderObjs...).Build()
+	}
+
+	r := &RepositoryReconciler{
+		ReconcilerBase:           util.NewReconcilerBase(&testManager{client: fakeClient, apiReader: apiReader, scheme: scheme}, recorder),
+		Log:                      ctrl.Log.WithName("test"),
+		ReconcilePeriod:          600,
+		OrphanedChartPolicy:      DeleteChartCleanupPolicy,
+		DisabledRepositoryPolicy: DeleteChartCleanupPolicy,
+		ConfigNamespace:          OpenShiftConfigNamespace,
+		ConnectTimeout:           5,
+		ResponseTimeout:          5,
+		MaxIndexSize:             1024 * 1024,
+		indexCache:               newIndexCache(),
+		dirtyCharts:              newDirtyCharts(),
+		ownDeletions:             newOwnDeletions(),
+		backoff:                  newRepositoryBackoff(time.Second, time.Minute),
+		schedule:                 newSyncSchedule(),
+	}
+
+	return r, recorder
+}
+
+// newTestRepository returns a cluster scoped repository of the URL that is created using the client of the reconciler
+func newTestRepository(t *testing.T, r *RepositoryReconciler, repositoryURL string, annotations map[string]string) *chartRepository {
+
+	instance := &helmv1beta1.HelmChartRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "repository",
+			Annotations: annotations,
+		},
+		Spec: helmv1beta1.HelmChartRepositorySpec{
+			ConnectionConfig: helmv1beta1.ConnectionConfig{URL: repositoryURL},
+		},
+	}
+
+	if err := r.GetClient().Create(context.Background(), instance); err != nil {
+		t.Fatal(err)
+	}
+
+	return &chartRepository{
+		object:          instance,
+		url:             repositoryURL,
+		configNamespace: r.ConfigNamespace,
+		configReader:    r.GetClient(),
+		allowLocalFiles: true,
+	}
+}
+
+// newTestHelmChart returns a chart of the repository labeled as created by the operator
+func newTestHelmChart(repositoryName string, chartName string) *redhatcopv1alpha1.HelmChart {
+	return &redhatcopv1alpha1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   repositoryName + "." + chartName,
+			UID:    k8stypes.UID(chartName),
+			Labels: map[string]string{utils.RepositoryLabelKey: repositoryName},
+		},
+		Spec: redhatcopv1alpha1.HelmChartSpec{
+			Name: chartName,
+		},
+	}
+}
+
+// getTestSyncStatus returns the sync status recorded for the repository
+func getTestSyncStatus(t *testing.T, r *RepositoryReconciler, repository *chartRepository) *redhatcopv1alpha1.HelmChartRepositorySync {
+
+	helmChartRepositorySync := &redhatcopv1alpha1.HelmChartRepositorySync{}
+
+	if err := r.GetClient().Get(context.Background(), k8stypes.NamespacedName{Name: repository.name()}, helmChartRepositorySync); err != nil {
+		t.Fatal(err)
+	}
+
+	return helmChartRepositorySync
+}
+
+func TestCleanupOrphanedCharts(t *testing.T) {
+
+	indexFile := &repo.IndexFile{Entries: map[string]repo.ChartVersions{
+		"nginx": {{Metadata: &chart.Metadata{Name: "nginx", Version: "1.0.0"}}},
+		"redis": {{Metadata: &chart.Metadata{Name: "redis", Version: "1.0.0"}}},
+	}}
+	indexedCharts := map[string]struct{}{"repository.nginx": {}}
+
+	tests := []struct {
+		name     string
+		policy   ChartCleanupPolicy
+		expected map[string]string
+	}{
+		{
+			name:     "delete policy",
+			policy:   DeleteChartCleanupPolicy,
+			expected: map[string]string{"repository.nginx": "", "other.redis": ""},
+		},
+		{
+			name:   "retain policy",
+			policy: RetainChartCleanupPolicy,
+			expected: map[string]string{
+				"repository.nginx": "",
+				"repository.redis": redhatcopv1alpha1.HelmChartExcludedReason,
+				"repository.mysql": redhatcopv1alpha1.HelmChartOrphanedReason,
+				"other.redis":      "",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// The chart excluded from the index was previously removed from the index
+			excludedHelmChart := newTestHelmChart("repository", "redis")
+			excludedHelmChart.Status.Conditions = []metav1.Condition{{
+				Type:   redhatcopv1alpha1.HelmChartOrphaned,
+				Status: metav1.ConditionTrue,
+				Reason: redhatcopv1alpha1.HelmChartOrphanedReason,
+			}}
+
+			r, _ := newTestReconciler(t, newTestHelmChart("repository", "nginx"), excludedHelmChart, newTestHelmChart("repository", "mysql"), newTestHelmChart("other", "redis"))
+			r.OrphanedChartPolicy = test.policy
+
+			if err := r.cleanupOrphanedCharts(context.Background(), newTestRepository(t, r, "https://example.com", nil), indexFile, indexedCharts); err != nil {
+				t.Fatal(err)
+			}
+
+			helmCharts := &redhatcopv1alpha1.HelmChartList{}
+			if err := r.GetClient().List(context.Background(), helmCharts); err != nil {
+				t.Fatal(err)
+			}
+
+			reasons := map[string]string{}
+			for _, helmChart := range helmCharts.Items {
+				condition, _ := apis.GetCondition(redhatcopv1alpha1.HelmChartOrphaned, helmChart.GetConditions())
+				reasons[helmChart.GetName()] = condition.Reason
+			}
+
+			if !reflect.DeepEqual(reasons, test.expected) {
+				t.Errorf("expected the orphaned reasons %v, got %v", test.expected, reasons)
+			}
+		})
+	}
+}
+
+func TestClearHelmChartCondition(t *testing.T) {
+
+	helmChart := newTestHelmChart("repository", "nginx")
+
+	if clearHelmChartCondition(helmChart, redhatcopv1alpha1.HelmChartOrphaned, redhatcopv1alpha1.HelmChartPresentInIndexReason, "present") {
+		t.Error("expected an absent condition not to change")
+	}
+
+	if len(helmChart.Status.Conditions) != 0 {
+		t.Errorf("expected an absent condition not to be added, got %v", helmChart.Status.Conditions)
+	}
+
+	helmChart.Status.Conditions = []metav1.Condition{{
+		Type:   redhatcopv1alpha1.HelmChartOrphaned,
+		Status: metav1.ConditionTrue,
+		Reason: redhatcopv1alpha1.HelmChartOrphanedReason,
+	}}
+
+	if !clearHelmChartCondition(helmChart, redhatcopv1alpha1.HelmChartOrphaned, redhatcopv1alpha1.HelmChartPresentInIndexReason, "present") {
+		t.Error("expected a true condition to change")
+	}
+
+	condition, _ := apis.GetCondition(redhatcopv1alpha1.HelmChartOrphaned, helmChart.GetConditions())
+	if condition.Status != metav1.ConditionFalse || condition.Reason != redhatcopv1alpha1.HelmChartPresentInIndexReason {
+		t.Errorf("expected the condition to be false with the reason %s, got %v", redhatcopv1alpha1.HelmChartPresentInIndexReason, condition)
+	}
+}
+
+func TestReconcileDisabledRepository(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		policy   ChartCleanupPolicy
+		expected bool
+	}{
+		{name: "delete policy", policy: DeleteChartCleanupPolicy, expected: false},
+		{name: "retain policy", policy: RetainChartCleanupPolicy, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			r, _ := newTestReconciler(t, newTestHelmChart("repository", "nginx"), newTestHelmChart("other", "nginx"))
+			r.DisabledRepositoryPolicy = test.policy
+
+			repository := newTestRepository(t, r, "https://example.com", nil)
+			repository.disabled = true
+
+			r.indexCache.Set(repository.key(), &indexCacheEntry{})
+
+			if _, err := r.reconcileRepository(context.Background(), repository); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, found := r.indexCache.Get(repository.key()); found {
+				t.Error("expected the cached index to be removed")
+			}
+
+			helmCharts, err := r.listHelmCharts(context.Background(), repository)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if retained := len(helmCharts) == 1; retained != test.expected {
+				t.Fatalf("expected the chart to be retained %t, got %d charts", test.expected, len(helmCharts))
+			}
+
+			if test.expected && !meta.IsStatusConditionTrue(helmCharts[0].GetConditions(), redhatcopv1alpha1.HelmChartRepositoryDisabled) {
+				t.Errorf("expected the %s condition, got %v", redhatcopv1alpha1.HelmChartRepositoryDisabled, helmCharts[0].GetConditions())
+			}
+
+			otherHelmChart := &redhatcopv1alpha1.HelmChart{}
+			if err := r.GetClient().Get(context.Background(), k8stypes.NamespacedName{Name: "other.nginx"}, otherHelmChart); err != nil {
+				t.Errorf("expected the charts of other repositories to be retained, got %v", err)
+			}
+
+			if condition := getTestSyncStatus(t, r, repository).Status.Conditions; !meta.IsStatusConditionFalse(condition, redhatcopv1alpha1.HelmChartRepositorySyncSynced) {
+				t.Errorf("expected the repository not to be synced, got %v", condition)
+			}
+		})
+	}
+}
+
+func TestReconcileRepositoryBeingDeleted(t *testing.T) {
+
+	r, _ := newTestReconciler(t, newTestHelmChart("repository", "nginx"))
+	repository := newTestRepository(t, r, "https://example.com", nil)
+
+	now := metav1.Now()
+	repository.object.SetDeletionTimestamp(&now)
+
+	r.indexCache.Set(repository.key(), &indexCacheEntry{})
+
+	result, err := r.reconcileRepository(context.Background(), repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Requeue || result.RequeueAfter != 0 {
+		t.Errorf("expected no requeue, got %+v", result)
+	}
+
+	if _, found := r.indexCache.Get(repository.key()); found {
+		t.Error("expected the cached index to be removed")
+	}
+
+	// The charts are removed by the garbage collector rather than the operator
+	if helmCharts, err := r.listHelmCharts(context.Background(), repository); err != nil || len(helmCharts) != 1 {
+		t.Errorf("expected the chart to be left to the garbage collector, got %d charts and error %v", len(helmCharts), err)
+	}
+}
+
+// testIndex is a repository index containing a single chart version
+const testIndex = "apiVersion: v1\nentries:\n  nginx:\n  - name: nginx\n    version: 1.0.0\n    urls:\n    - https://example.com/nginx-1.0.0.tgz\n"
+
+func TestReconcileRepositoryRefreshRequested(t *testing.T) {
+
+	available := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Write([]byte(testIndex))
+	}))
+	defer server.Close()
+
+	r, _ := newTestReconciler(t)
+	repository := newTestRepository(t, r, server.URL, map[string]string{refreshRequestedAnnotation: "1"})
+
+	r.indexCache.Set(repository.key(), &indexCacheEntry{URL: server.URL + "/index.yaml"})
+
+	if _, err := r.reconcileRepository(context.Background(), repository); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := r.indexCache.Get(repository.key()); found {
+		t.Error("expected the cached index to be removed")
+	}
+
+	if lastHandled := getTestSyncStatus(t, r, repository).Status.LastHandledRefreshRequest; lastHandled != "" {
+		t.Errorf("expected the failed refresh not to be acknowledged, got %q", lastHandled)
+	}
+
+	available = true
+
+	if _, err := r.reconcileRepository(context.Background(), repository); err != nil {
+		t.Fatal(err)
+	}
+
+	if lastHandled := getTestSyncStatus(t, r, repository).Status.LastHandledRefreshRequest; lastHandled != "1" {
+		t.Errorf("expected the refresh to be acknowledged, got %q", lastHandled)
+	}
+}
+
+func TestFetchIndexFileMaxSize(t *testing.T) {
+
+	index := []byte("apiVersion: v1\nentries:\n  nginx:\n  - name: nginx\n    version: 1.0.0\n")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write(index)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		maxIndexSize int
+		expectError  bool
+	}{
+		{name: "index within the maximum size", maxIndexSize: len(index), expectError: false},
+		{name: "index exceeding the maximum size", maxIndexSize: len(index) - 1, expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			r := &RepositoryReconciler{MaxIndexSize: test.maxIndexSize, indexCache: newIndexCache()}
+			repository := &chartRepository{object: &helmv1beta1.HelmChartRepository{ObjectMeta: metav1.ObjectMeta{Name: "repository"}}}
+
+			cacheEntry, _, err := r.fetchIndexFile(context.Background(), repository, server.Client(), server.URL+"/index.yaml")
+
+			if test.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cacheEntry.Size != len(index) || len(cacheEntry.IndexFile.Entries["nginx"]) != 1 {
+				t.Errorf("unexpected index %v of size %d", cacheEntry.IndexFile.Entries, cacheEntry.Size)
+			}
+		})
+	}
+}
+
+func TestFetchIndexFileConditional(t *testing.T) {
+
+	const etag = `"index-1"`
+	const lastModified = "Tue, 01 Jun 2021 00:00:00 GMT"
+
+	index := []byte("apiVersion: v1\nentries:\n  nginx:\n  - name: nginx\n    version: 1.0.0\n")
+
+	var requestHeaders http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestHeaders = req.Header
+
+		if req.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write(index)
+	}))
+	defer server.Close()
+
+	r := &RepositoryReconciler{MaxIndexSize: len(index), indexCache: newIndexCache()}
+	repository := &chartRepository{object: &helmv1beta1.HelmChartRepository{ObjectMeta: metav1.ObjectMeta{Name: "repository", Generation: 1}}}
+	indexURL := server.URL + "/index.yaml"
+
+	cacheEntry, modified, err := r.fetchIndexFile(context.Background(), repository, server.Client(), indexURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !modified || requestHeaders.Get("If-None-Match") != "" || requestHeaders.Get("If-Modified-Since") != "" {
+		t.Fatalf("expected an unconditional request returning a modified index, got modified %t and headers %v", modified, requestHeaders)
+	}
+
+	if cacheEntry.ETag != etag || cacheEntry.LastModified != lastModified || cacheEntry.Generation != 1 || cacheEntry.URL != indexURL {
+		t.Fatalf("unexpected cache entry %+v", cacheEntry)
+	}
+
+	r.indexCache.Set(repository.key(), cacheEntry)
+
+	t.Run("not modified", func(t *testing.T) {
+
+		notModifiedEntry, modified, err := r.fetchIndexFile(context.Background(), repository, server.Client(), indexURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if requestHeaders.Get("If-None-Match") != etag || requestHeaders.Get("If-Modified-Since") != lastModified {
+			t.Errorf("expected conditional request headers, got %v", requestHeaders)
+		}
+
+		if modified || notModifiedEntry != cacheEntry {
+			t.Errorf("expected the cached entry without modifications, got modified %t", modified)
+		}
+	})
+
+	t.Run("changed URL", func(t *testing.T) {
+
+		_, modified, err := r.fetchIndexFile(context.Background(), repository, server.Client(), server.URL+"/charts/index.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !modified || requestHeaders.Get("If-None-Match") != "" || requestHeaders.Get("If-Modified-Since") != "" {
+			t.Errorf("expected an unconditional request returning a modified index, got modified %t and headers %v", modified, requestHeaders)
+		}
+	})
+
+	t.Run("changed generation", func(t *testing.T) {
+
+		updatedRepository := &chartRepository{object: &helmv1beta1.HelmChartRepository{ObjectMeta: metav1.ObjectMeta{Name: "repository", Generation: 2}}}
+
+		updatedEntry, modified, err := r.fetchIndexFile(context.Background(), updatedRepository, server.Client(), indexURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !modified || requestHeaders.Get("If-None-Match") != "" || requestHeaders.Get("If-Modified-Since") != "" {
+			t.Errorf("expected an unconditional request returning a modified index, got modified %t and headers %v", modified, requestHeaders)
+		}
+
+		if updatedEntry.Generation != 2 {
+			t.Errorf("expected generation 2, got %d", updatedEntry.Generation)
+		}
+	})
+}
+
+// setTestClock replaces the clock of the reconciler until the test completes
+func setTestClock(t *testing.T) *kubeclock.FakeClock {
+
+	fakeClock := kubeclock.NewFakeClock(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))
+	previousClock := clock
+	clock = fakeClock
+
+	t.Cleanup(func() {
+		clock = previousClock
+	})
+
+	return fakeClock
+}
+
+func newManagedHelmChart(t *testing.T, generation int64, displayName string) *redhatcopv1alpha1.HelmChart {
+
+	helmChart := &redhatcopv1alpha1.HelmChart{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HelmChart",
+			APIVersion: redhatcopv1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "repository.nginx",
+			UID:        k8stypes.UID("nginx"),
+			Generation: generation,
+		},
+		Spec: redhatcopv1alpha1.HelmChartSpec{
+			Name:                  "nginx",
+			RepositoryDisplayName: "Charts",
+		},
+	}
+
+	specHash, err := utils.HashHelmChartSpec(&helmChart.Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helmChart.Annotations = map[string]string{utils.SpecHashAnnotationKey: specHash}
+	helmChart.Spec.RepositoryDisplayName = displayName
+
+	return helmChart
+}
+
+func TestIsHelmChartCurrent(t *testing.T) {
+
+	relabeled := newManagedHelmChart(t, 1, "Charts")
+	relabeled.Labels = map[string]string{utils.RepositoryLabelKey: "repository"}
+
+	rehashed := newManagedHelmChart(t, 1, "Charts")
+	rehashed.Annotations[utils.SpecHashAnnotationKey] = "modified"
+
+	orphaned := newManagedHelmChart(t, 1, "Charts")
+	orphaned.Status.Conditions = []metav1.Condition{{Type: redhatcopv1alpha1.HelmChartOrphaned, Status: metav1.ConditionTrue, Reason: redhatcopv1alpha1.HelmChartOrphanedReason}}
+
+	tests := []struct {
+		name     string
+		existing *redhatcopv1alpha1.HelmChart
+		expected bool
+	}{
+		{name: "unchanged", existing: newManagedHelmChart(t, 1, "Charts"), expected: true},
+		{name: "different spec hash", existing: rehashed, expected: false},
+		{name: "different labels", existing: relabeled, expected: false},
+		{name: "modified outside of the operator", existing: newManagedHelmChart(t, 1, "Modified"), expected: false},
+		{name: "orphaned", existing: orphaned, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if current := isHelmChartCurrent(test.existing, newManagedHelmChart(t, 1, "Charts")); current != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, current)
+			}
+		})
+	}
+}
+
+func newManagedHelmChartVersion(t *testing.T, appVersion string) *redhatcopv1alpha1.HelmChartVersion {
+
+	helmChartVersion := &redhatcopv1alpha1.HelmChartVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "repository.nginx.1.0.0",
+			Labels: map[string]string{utils.RepositoryLabelKey: "repository", utils.ChartLabelKey: "nginx"},
+		},
+		Spec: redhatcopv1alpha1.HelmChartVersionSpec{
+			Version:    "1.0.0",
+			AppVersion: "1.19.0",
+		},
+	}
+
+	specHash, err := utils.HashHelmChartVersionSpec(&helmChartVersion.Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helmChartVersion.Annotations = map[string]string{utils.SpecHashAnnotationKey: specHash}
+	helmChartVersion.Spec.AppVersion = appVersion
+
+	return helmChartVersion
+}
+
+func TestIsHelmChartVersionCurrent(t *testing.T) {
+
+	relabeled := newManagedHelmChartVersion(t, "1.19.0")
+	relabeled.Labels[utils.ChartLabelKey] = "redis"
+
+	rehashed := newManagedHelmChartVersion(t, "1.19.0")
+	rehashed.Annotations[utils.SpecHashAnnotationKey] = "modified"
+
+	tests := []struct {
+		name     string
+		existing *redhatcopv1alpha1.HelmChartVersion
+		expected bool
+	}{
+		{name: "unchanged", existing: newManagedHelmChartVersion(t, "1.19.0"), expected: true},
+		{name: "different spec hash", existing: rehashed, expected: false},
+		{name: "spec modified outside of the operator", existing: newManagedHelmChartVersion(t, "1.20.0"), expected: false},
+		{name: "different labels", existing: relabeled, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if current := isHelmChartVersionCurrent(test.existing, newManagedHelmChartVersion(t, "1.19.0")); current != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, current)
+			}
+		})
+	}
+}
+
+func TestApplyHelmChartSkipsUnchanged(t *testing.T) {
+
+	fakeClock := setTestClock(t)
+
+	r, _ := newTestReconciler(t)
+	repository := newTestRepository(t, r, "https://example.com", nil)
+
+	getHelmChart := func() *redhatcopv1alpha1.HelmChart {
+		helmChart := &redhatcopv1alpha1.HelmChart{}
+		if err := r.GetClient().Get(context.Background(), k8stypes.NamespacedName{Name: "repository.nginx"}, helmChart); err != nil {
+			t.Fatal(err)
+		}
+		return helmChart
+	}
+
+	if err := r.applyHelmChart(context.Background(), repository, newManagedHelmChart(t, 1, "Charts"), nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	created := getHelmChart()
+	createdTime := fakeClock.Now()
+
+	if created.Status.LastUpdateTimestamp == nil || !created.Status.LastUpdateTimestamp.Time.Equal(createdTime) || !created.Status.LastCheckedTimestamp.Time.Equal(createdTime) {
+		t.Fatalf("expected the timestamps of the created chart to be set, got %+v", created.Status)
+	}
+
+	t.Run("unchanged within the refresh period", func(t *testing.T) {
+
+		fakeClock.Step(time.Hour)
+
+		if err := r.applyHelmChart(context.Background(), repository, newManagedHelmChart(t, 1, "Charts"), nil, false); err != nil {
+			t.Fatal(err)
+		}
+
+		if helmChart := getHelmChart(); helmChart.ResourceVersion != created.ResourceVersion {
+			t.Errorf("expected the unchanged chart not to be written, got resource version %s instead of %s", helmChart.ResourceVersion, created.ResourceVersion)
+		}
+	})
+
+	t.Run("unchanged after the refresh period", func(t *testing.T) {
+
+		fakeClock.Step(lastCheckedRefreshPeriod)
+
+		if err := r.applyHelmChart(context.Background(), repository, newManagedHelmChart(t, 1, "Charts"), nil, false); err != nil {
+			t.Fatal(err)
+		}
+
+		helmChart := getHelmChart()
+
+		if !helmChart.Status.LastCheckedTimestamp.Time.Equal(fakeClock.Now()) || !helmChart.Status.LastUpdateTimestamp.Time.Equal(createdTime) {
+			t.Errorf("expected only the last checked timestamp to be refreshed, got %+v", helmChart.Status)
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+
+		fakeClock.Step(time.Hour)
+
+		changed := newManagedHelmChart(t, 1, "Updated")
+		specHash, err := utils.HashHelmChartSpec(&changed.Spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed.Annotations[utils.SpecHashAnnotationKey] = specHash
+
+		if err := r.applyHelmChart(context.Background(), repository, changed, nil, false); err != nil {
+			t.Fatal(err)
+		}
+
+		helmChart := getHelmChart()
+
+		if helmChart.Spec.RepositoryDisplayName != "Updated" || !helmChart.Status.LastUpdateTimestamp.Time.Equal(fakeClock.Now()) {
+			t.Errorf("expected the chart and its last update timestamp to be updated, got %+v", helmChart)
+		}
+	})
+}
+
+func TestRefreshLastChecked(t *testing.T) {
+
+	fakeClock := setTestClock(t)
+
+	recentTime := metav1.NewTime(fakeClock.Now().Add(-time.Hour))
+	staleTime := metav1.NewTime(fakeClock.Now().Add(-lastCheckedRefreshPeriod))
+
+	recent := newTestHelmChart("repository", "nginx")
+	recent.Status.LastCheckedTimestamp = &recentTime
+
+	stale := newTestHelmChart("repository", "redis")
+	stale.Status.LastCheckedTimestamp = &staleTime
+
+	orphaned := newTestHelmChart("repository", "mysql")
+	orphaned.Status.LastCheckedTimestamp = &staleTime
+	orphaned.Status.Conditions = []metav1.Condition{{Type: redhatcopv1alpha1.HelmChartOrphaned, Status: metav1.ConditionTrue, Reason: redhatcopv1alpha1.HelmChartOrphanedReason}}
+
+	r, _ := newTestReconciler(t, recent, stale, orphaned)
+
+	if err := r.refreshLastChecked(context.Background(), newTestRepository(t, r, "https://example.com", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]time.Time{
+		"repository.nginx": recentTime.Time,
+		"repository.redis": fakeClock.Now(),
+		"repository.mysql": staleTime.Time,
+	}
+
+	for name, expectedTime := range expected {
+		helmChart := &redhatcopv1alpha1.HelmChart{}
+		if err := r.GetClient().Get(context.Background(), k8stypes.NamespacedName{Name: name}, helmChart); err != nil {
+			t.Fatal(err)
+		}
+
+		if !helmChart.Status.LastCheckedTimestamp.Time.Equal(expectedTime) {
+			t.Errorf("expected chart %s to be last checked at %v, got %v", name, expectedTime, helmChart.Status.LastCheckedTimestamp)
+		}
+	}
+}
+
+func TestNewChartSyncError(t *testing.T) {
+
+	tests := []struct {
+		name                 string
+		failedCharts         int
+		message              string
+		expectedMessage      string
+		expectedStatusCharts int
+		expectedOmitted      int
+	}{
+		{name: "no failed charts", failedCharts: 0},
+		{name: "reported failed charts", failedCharts: 2, message: "failed", expectedMessage: "Failed to synchronize 2 charts: [chart chart-000: failed, chart chart-001: failed]", expectedStatusCharts: 2},
+		{name: "omitted failed charts", failedCharts: maxStatusChartFailures + 5, message: "failed", expectedMessage: fmt.Sprintf("and %d more", maxStatusChartFailures+5-maxReportedChartFailures), expectedStatusCharts: maxStatusChartFailures, expectedOmitted: 5},
+		{name: "truncated failure messages", failedCharts: 1, message: strings.Repeat("x", maxChartFailureMessageLength+1), expectedMessage: "Failed to synchronize 1 charts", expectedStatusCharts: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			failedCharts := map[string]error{}
+			for i := 0; i < test.failedCharts; i++ {
+				failedCharts[fmt.Sprintf("chart-%03d", i)] = errors.New(test.message)
+			}
+
+			helmChartRepositorySync := &redhatcopv1alpha1.HelmChartRepositorySync{}
+			err := (&RepositoryReconciler{}).newChartSyncError(helmChartRepositorySync, failedCharts, 0, time.Hour)
+
+			if test.failedCharts == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.expectedMessage) {
+				t.Errorf("expected message containing %q, got %v", test.expectedMessage, err)
+			}
+
+			if strings.Count(err.Error(), "chart chart-") > maxReportedChartFailures {
+				t.Errorf("expected at most %d reported charts, got %q", maxReportedChartFailures, err.Error())
+			}
+
+			status := helmChartRepositorySync.Status
+
+			if count := len(status.FailedCharts); count != test.expectedStatusCharts {
+				t.Errorf("expected %d failed charts in the status, got %d", test.expectedStatusCharts, count)
+			}
+
+			if status.OmittedFailedCharts != test.expectedOmitted {
+				t.Errorf("expected %d omitted failed charts, got %d", test.expectedOmitted, status.OmittedFailedCharts)
+			}
+
+			for _, failedChart := range status.FailedCharts {
+				if length := len([]rune(failedChart.Message)); length > maxChartFailureMessageLength {
+					t.Errorf("expected a message of at most %d characters for chart %s, got %d", maxChartFailureMessageLength, failedChart.Name, length)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+
+	if message := truncateMessage("short"); message != "short" {
+		t.Errorf("expected the message to be unchanged, got %q", message)
+	}
+
+	message := truncateMessage(strings.Repeat("é", maxConditionMessageLength+1))
+
+	if length := len([]rune(message)); length != maxConditionMessageLength {
+		t.Errorf("expected %d characters, got %d", maxConditionMessageLength, length)
+	}
+
+	if !strings.HasSuffix(message, "...") {
+		t.Errorf("expected the truncated message to end with an ellipsis")
+	}
+}
+
+func TestIsModifiedOutsideOperator(t *testing.T) {
+
+	r := &RepositoryReconciler{ownDeletions: newOwnDeletions()}
+	modified := r.isModifiedOutsideOperator()
+
+	existing := newManagedHelmChart(t, 1, "Charts")
+
+	tests := []struct {
+		name     string
+		matches  bool
+		expected bool
+	}{
+		{name: "created", matches: modified.Create(event.CreateEvent{Object: existing}), expected: false},
+		{name: "updated by the operator", matches: modified.Update(event.UpdateEvent{ObjectOld: existing, ObjectNew: newManagedHelmChart(t, 2, "Charts")}), expected: false},
+		{name: "updated outside of the operator", matches: modified.Update(event.UpdateEvent{ObjectOld: existing, ObjectNew: newManagedHelmChart(t, 2, "Modified")}), expected: true},
+		{name: "status updated", matches: modified.Update(event.UpdateEvent{ObjectOld: existing, ObjectNew: newManagedHelmChart(t, 1, "Modified")}), expected: false},
+		{name: "deleted outside of the operator", matches: modified.Delete(event.DeleteEvent{Object: existing}), expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.matches != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, test.matches)
+			}
+		})
+	}
+
+	r.ownDeletions.Add(existing.UID)
+
+	if modified.Delete(event.DeleteEvent{Object: existing}) {
+		t.Error("expected deletions by the operator to be ignored")
+	}
+
+	if !modified.Delete(event.DeleteEvent{Object: existing}) {
+		t.Error("expected deletions by the operator to only be ignored once")
+	}
+}
+
+func TestIsModifiedOutsideOperatorChartVersions(t *testing.T) {
+
+	now := metav1.Now()
+
+	newHelmChart := func(name string, uid string, deletionTimestamp *metav1.Time) *redhatcopv1alpha1.HelmChart {
+		return &redhatcopv1alpha1.HelmChart{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				UID:               k8stypes.UID(uid),
+				DeletionTimestamp: deletionTimestamp,
+				Finalizers:        []string{"test"},
+			},
+		}
+	}
+
+	newHelmChartVersion := func(chartName string, chartUID string) *redhatcopv1alpha1.HelmChartVersion {
+		controller := true
+		return &redhatcopv1alpha1.HelmChartVersion{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: chartName + ".1.0.0",
+				UID:  k8stypes.UID(chartName + "-1.0.0"),
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: redhatcopv1alpha1.GroupVersion.String(),
+					Kind:       "HelmChart",
+					Name:       chartName,
+					UID:        k8stypes.UID(chartUID),
+					Controller: &controller,
+				}},
+			},
+		}
+	}
+
+	r, _ := newTestReconciler(t,
+		newHelmChart("repository.nginx", "nginx", nil),
+		newHelmChart("repository.redis", "redis", &now),
+		newHelmChart("repository.mysql", "mysql-2", nil),
+	)
+	modified := r.isModifiedOutsideOperator()
+
+	r.ownDeletions.Add(k8stypes.UID("deleting"))
+
+	tests := []struct {
+		name     string
+		version  *redhatcopv1alpha1.HelmChartVersion
+		expected bool
+	}{
+		{name: "version of an existing chart", version: newHelmChartVersion("repository.nginx", "nginx"), expected: true},
+		{name: "version of a chart deleted by the operator", version: newHelmChartVersion("repository.deleting", "deleting"), expected: false},
+		{name: "version of a deleted chart", version: newHelmChartVersion("repository.postgresql", "postgresql"), expected: false},
+		{name: "version of a chart being deleted", version: newHelmChartVersion("repository.redis", "redis"), expected: false},
+		{name: "version of a replaced chart", version: newHelmChartVersion("repository.mysql", "mysql"), expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches := modified.Delete(event.DeleteEvent{Object: test.version}); matches != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, matches)
+			}
+		})
+	}
+
+	if !r.ownDeletions.Take(k8stypes.UID("deleting")) {
+		t.Error("expected the deletion of the chart to be retained for its own delete event")
+	}
+}
+
+func TestDeleteOwnResource(t *testing.T) {
+
+	existing := newManagedHelmChart(t, 1, "Charts")
+	r, _ := newTestReconciler(t, existing)
+
+	if err := r.deleteOwnResource(context.Background(), existing); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.ownDeletions.Take(existing.UID) {
+		t.Error("expected the deletion to be recorded")
+	}
+
+	if err := r.deleteOwnResource(context.Background(), existing); err != nil {
+		t.Fatalf("expected deleting a missing chart to succeed, got %v", err)
+	}
+
+	if r.ownDeletions.Take(existing.UID) {
+		t.Error("expected the deletion of a missing chart not to be recorded")
+	}
+}
+
+func TestForgetRepository(t *testing.T) {
+
+	r := &RepositoryReconciler{
+		indexCache:  newIndexCache(),
+		dirtyCharts: newDirtyCharts(),
+		backoff:     newRepositoryBackoff(time.Second, time.Minute),
+		schedule:    newSyncSchedule(),
+	}
+
+	r.indexCache.Set("repository", &indexCacheEntry{})
+	r.dirtyCharts.Add("repository", "nginx")
+	r.dirtyCharts.Add("other", "nginx")
+	r.backoff.Next("repository")
+
+	r.forgetRepository("", "repository")
+
+	if _, found := r.indexCache.Get("repository"); found {
+		t.Error("expected the cached index to be removed")
+	}
+
+	if chartNames := r.dirtyCharts.Take("repository"); len(chartNames) != 0 {
+		t.Errorf("expected no modified charts, got %v", chartNames)
+	}
+
+	if chartNames := r.dirtyCharts.Take("other"); len(chartNames) != 1 {
+		t.Errorf("expected the modified charts of other repositories to be retained, got %v", chartNames)
+	}
+
+	if delay := r.backoff.Next("repository"); delay >= time.Second*2 {
+		t.Errorf("expected the backoff to be reset, got %v", delay)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// retryBaseDelay is the delay before a request is first retried when the server does not specify Retry-After
+	retryBaseDelay = time.Second
+
+	// maxRetryAfter is the longest Retry-After honored. Longer delays are left to the repository backoff
+	maxRetryAfter = time.Minute
+
+	// maxDrainSize limits the amount of a discarded response body read to allow the connection to be reused
+	maxDrainSize = 64 * 1024
+)
+
+// retryTransport retries requests that fail with a server error or are rate limited, honoring the Retry-After header.
+// Each attempt, including reading its response body, is limited by the attempt timeout so that retries and the delays
+// between them are not cut short
+type retryTransport struct {
+	base           http.RoundTripper
+	maxRetries     int
+	attemptTimeout time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	for attempt := 0; ; attempt++ {
+
+		resp, err := t.roundTripAttempt(req)
+
+		if err != nil || attempt >= t.maxRetries || !isRetryableStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		// Requests with a body can only be retried when the body can be recreated
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		delay, ok := retryDelay(resp, attempt)
+		if !ok {
+			return resp, nil
+		}
+
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxDrainSize))
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// roundTripAttempt sends a single attempt of the request, limited by the attempt timeout until the response body is
+// closed
+func (t *retryTransport) roundTripAttempt(req *http.Request) (*http.Response, error) {
+
+	if t.attemptTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.attemptTimeout)
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelOnCloseBody releases the context of an attempt once its response body is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// isRetryableStatus determines whether a response with the status code may succeed when the request is retried
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// retryDelay returns the delay before the request is retried. The delay requested using the Retry-After header is
+// used when present, otherwise the delay doubles with each attempt. False is returned when the server requests a
+// delay longer than maxRetryAfter
+func retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+
+	retryAfter := resp.Header.Get("Retry-After")
+
+	if retryAfter == "" {
+		return retryBaseDelay * time.Duration(1<<uint(attempt)), true
+	}
+
+	var delay time.Duration
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil {
+		delay = date.Sub(clock.Now())
+	} else {
+		return retryBaseDelay * time.Duration(1<<uint(attempt)), true
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	return delay, delay <= maxRetryAfter
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+
+	tests := []struct {
+		name             string
+		statusCodes      []int
+		retryAfter       string
+		maxRetries       int
+		expectedStatus   int
+		expectedAttempts int32
+	}{
+		{name: "success", statusCodes: []int{http.StatusOK}, maxRetries: 3, expectedStatus: http.StatusOK, expectedAttempts: 1},
+		{name: "server error retried", statusCodes: []int{http.StatusServiceUnavailable, http.StatusOK}, retryAfter: "0", maxRetries: 3, expectedStatus: http.StatusOK, expectedAttempts: 2},
+		{name: "rate limit retried", statusCodes: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}, retryAfter: "0", maxRetries: 3, expectedStatus: http.StatusOK, expectedAttempts: 3},
+		{name: "client error not retried", statusCodes: []int{http.StatusNotFound}, maxRetries: 3, expectedStatus: http.StatusNotFound, expectedAttempts: 1},
+		{name: "retries exhausted", statusCodes: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, retryAfter: "0", maxRetries: 2, expectedStatus: http.StatusBadGateway, expectedAttempts: 3},
+		{name: "retry after exceeding the maximum", statusCodes: []int{http.StatusServiceUnavailable, http.StatusOK}, retryAfter: strconv.Itoa(int(2 * maxRetryAfter / time.Second)), maxRetries: 3, expectedStatus: http.StatusServiceUnavailable, expectedAttempts: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			var attempts int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				attempt := atomic.AddInt32(&attempts, 1)
+
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+
+				w.WriteHeader(test.statusCodes[attempt-1])
+			}))
+			defer server.Close()
+
+			httpClient := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, maxRetries: test.maxRetries, attemptTimeout: time.Minute}}
+
+			resp, err := httpClient.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, resp.StatusCode)
+			}
+
+			if attempts != test.expectedAttempts {
+				t.Errorf("expected %d attempts, got %d", test.expectedAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransportAttemptTimeout(t *testing.T) {
+
+	var attempts int32
+
+	// The first attempt exceeds the attempt timeout while the response body is read
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, maxRetries: 1, attemptTimeout: 200 * time.Millisecond}}
+
+	// The delay before the retry is not part of the attempt timeout
+	start := time.Now()
+
+	resp, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < retryBaseDelay {
+		t.Errorf("expected the request to be retried after %s, got %s", retryBaseDelay, elapsed)
+	}
+
+	_, err = ioutil.ReadAll(resp.Body)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the attempt to time out, got %v", err)
+	}
+}
@@ -3,9 +3,12 @@ module github.com/redhat-cop/helm-chart-repository-operator
 go 1.15
 
 require (
+	github.com/Masterminds/semver/v3 v3.1.1
 	github.com/go-logr/logr v0.3.0
 	github.com/openshift/api v0.0.0-20210202165416-a9e731090f5e
+	github.com/prometheus/client_golang v1.7.1
 	github.com/redhat-cop/operator-utils v1.1.0
+	golang.org/x/net v0.0.0-20201110031124-69a78807bb2b
 	helm.sh/helm/v3 v3.5.0
 	k8s.io/api v0.20.1 //ct
 	k8s.io/apimachinery v0.20.1
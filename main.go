@@ -25,6 +25,7 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	configv1 "github.com/openshift/api/config/v1"
 	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -35,6 +36,7 @@ import (
 
 	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
 	"github.com/redhat-cop/helm-chart-repository-operator/controllers"
+	projecthelmv1beta1 "github.com/redhat-cop/helm-chart-repository-operator/pkg/apis/helm/v1beta1"
 	"github.com/redhat-cop/operator-utils/pkg/util"
 	//+kubebuilder:scaffold:imports
 )
@@ -47,6 +49,25 @@ var (
 const (
 	repositoryReconcilePeriodKey            = "REPOSITORY_RECONCILE_PERIOD_SECONDS"
 	defaultRepositoryReconcilePeriodSeconds = 600
+	orphanedChartPolicyKey                  = "ORPHANED_CHART_POLICY"
+	disabledRepositoryPolicyKey             = "DISABLED_REPOSITORY_POLICY"
+	failureBackoffMinKey                    = "REPOSITORY_FAILURE_BACKOFF_MIN_SECONDS"
+	defaultFailureBackoffMinSeconds         = 10
+	failureBackoffMaxKey                    = "REPOSITORY_FAILURE_BACKOFF_MAX_SECONDS"
+	defaultFailureBackoffMaxSeconds         = 600
+	localIndexDirectoryKey                  = "LOCAL_INDEX_DIRECTORY"
+	connectTimeoutKey                       = "REPOSITORY_CONNECT_TIMEOUT_SECONDS"
+	defaultConnectTimeoutSeconds            = 10
+	responseTimeoutKey                      = "REPOSITORY_RESPONSE_TIMEOUT_SECONDS"
+	defaultResponseTimeoutSeconds           = 120
+	fetchRetriesKey                         = "REPOSITORY_FETCH_RETRIES"
+	defaultFetchRetries                     = 3
+	maxIndexSizeKey                         = "REPOSITORY_MAX_INDEX_SIZE_BYTES"
+	defaultMaxIndexSizeBytes                = 64 * 1024 * 1024
+	configNamespaceKey                      = "CONFIG_NAMESPACE"
+	retainMaxVersionsKey                    = "VERSION_RETENTION_MAX_VERSIONS"
+	retainMaxAgeDaysKey                     = "VERSION_RETENTION_MAX_AGE_DAYS"
+	retainLatestPatchKey                    = "VERSION_RETENTION_LATEST_PATCH"
 )
 
 func init() {
@@ -54,6 +75,8 @@ func init() {
 
 	utilruntime.Must(redhatcopv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(helmv1beta1.AddToScheme(scheme))
+	utilruntime.Must(projecthelmv1beta1.AddToScheme(scheme))
+	utilruntime.Must(configv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -61,11 +84,14 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var configNamespace string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&configNamespace, "config-namespace", lookupStringEnv(configNamespaceKey, controllers.OpenShiftConfigNamespace),
+		"The namespace containing the ConfigMaps and Secrets referenced by repositories.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -89,28 +115,74 @@ func main() {
 	}
 
 	// Reconcile Period
-	reconcilePeriod := defaultRepositoryReconcilePeriodSeconds
+	reconcilePeriod := lookupIntEnv(repositoryReconcilePeriodKey, defaultRepositoryReconcilePeriodSeconds)
+	setupLog.Info("Custom Reconcile Period", "Unit", reconcilePeriod)
 
-	reconcilePeriodEnv, ok := os.LookupEnv(repositoryReconcilePeriodKey)
+	// Failure Backoff
+	failureBackoffMin := lookupPositiveIntEnv(failureBackoffMinKey, defaultFailureBackoffMinSeconds)
+	failureBackoffMax := lookupPositiveIntEnv(failureBackoffMaxKey, defaultFailureBackoffMaxSeconds)
+	setupLog.Info("Repository Failure Backoff", "Min", failureBackoffMin, "Max", failureBackoffMax)
 
-	if ok {
-		reconcilePeriodEnvInt, err := strconv.Atoi(reconcilePeriodEnv)
+	// Repository Connections
+	connectTimeout := lookupPositiveIntEnv(connectTimeoutKey, defaultConnectTimeoutSeconds)
+	responseTimeout := lookupPositiveIntEnv(responseTimeoutKey, defaultResponseTimeoutSeconds)
+	fetchRetries := lookupIntEnv(fetchRetriesKey, defaultFetchRetries)
+	maxIndexSize := lookupPositiveIntEnv(maxIndexSizeKey, defaultMaxIndexSizeBytes)
+	setupLog.Info("Repository Connections", "ConnectTimeout", connectTimeout, "ResponseTimeout", responseTimeout, "Retries", fetchRetries, "MaxIndexSize", maxIndexSize)
 
-		if err == nil {
-			reconcilePeriod = reconcilePeriodEnvInt
+	// Version Retention
+	retainMaxVersions := lookupIntEnv(retainMaxVersionsKey, 0)
+	retainMaxAgeDays := lookupIntEnv(retainMaxAgeDaysKey, 0)
+	retainLatestPatch := lookupBoolEnv(retainLatestPatchKey, false)
+	setupLog.Info("Version Retention", "MaxVersions", retainMaxVersions, "MaxAgeDays", retainMaxAgeDays, "LatestPatch", retainLatestPatch)
+
+	// Chart Cleanup Policies
+	orphanedChartPolicy := lookupChartCleanupPolicy(orphanedChartPolicyKey)
+	setupLog.Info("Orphaned Chart Policy", "Policy", orphanedChartPolicy)
+
+	disabledRepositoryPolicy := lookupChartCleanupPolicy(disabledRepositoryPolicyKey)
+	setupLog.Info("Disabled Repository Policy", "Policy", disabledRepositoryPolicy)
+
+	// Config Namespace
+	setupLog.Info("Config Namespace", "Namespace", configNamespace)
+
+	// Local Index Directory
+	localIndexDirectory := os.Getenv(localIndexDirectoryKey)
+	setupLog.Info("Local Index Directory", "Directory", localIndexDirectory)
+
+	newRepositoryReconciler := func(name string) controllers.RepositoryReconciler {
+		return controllers.RepositoryReconciler{
+			ReconcilerBase:           util.NewReconcilerBase(mgr, mgr.GetEventRecorderFor(name+"_controller")),
+			Log:                      ctrl.Log.WithName("controllers").WithName(name),
+			ReconcilePeriod:          reconcilePeriod,
+			OrphanedChartPolicy:      orphanedChartPolicy,
+			DisabledRepositoryPolicy: disabledRepositoryPolicy,
+			FailureBackoffMin:        failureBackoffMin,
+			FailureBackoffMax:        failureBackoffMax,
+			LocalIndexDirectory:      localIndexDirectory,
+			ConfigNamespace:          configNamespace,
+			ConnectTimeout:           connectTimeout,
+			ResponseTimeout:          responseTimeout,
+			FetchRetries:             fetchRetries,
+			MaxIndexSize:             maxIndexSize,
+			RetainMaxVersions:        retainMaxVersions,
+			RetainMaxAgeDays:         retainMaxAgeDays,
+			RetainLatestPatch:        retainLatestPatch,
 		}
 	}
 
-	setupLog.Info("Custom Reconcile Period", "Unit", reconcilePeriod)
-
 	if err = (&controllers.HelmChartRepositoryReconciler{
-		ReconcilerBase:  util.NewReconcilerBase(mgr, mgr.GetEventRecorderFor("HelmChartRepository_controller")),
-		Log:             ctrl.Log.WithName("controllers").WithName("HelmChartRepository"),
-		ReconcilePeriod: reconcilePeriod,
+		RepositoryReconciler: newRepositoryReconciler("HelmChartRepository"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HelmChartRepository")
 		os.Exit(1)
 	}
+	if err = (&controllers.ProjectHelmChartRepositoryReconciler{
+		RepositoryReconciler: newRepositoryReconciler("ProjectHelmChartRepository"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ProjectHelmChartRepository")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -128,3 +200,80 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// lookupIntEnv returns the integer set in the given environment variable or the default value when unset or invalid
+func lookupIntEnv(key string, defaultValue int) int {
+
+	value, ok := os.LookupEnv(key)
+
+	if ok {
+		valueInt, err := strconv.Atoi(value)
+
+		if err == nil {
+			return valueInt
+		}
+
+		setupLog.Info("Ignoring Invalid Integer", "Variable", key, "Value", value)
+	}
+
+	return defaultValue
+}
+
+// lookupPositiveIntEnv returns the positive integer set in the given environment variable or the default value when
+// unset, invalid or not positive
+func lookupPositiveIntEnv(key string, defaultValue int) int {
+
+	value := lookupIntEnv(key, defaultValue)
+
+	if value <= 0 {
+		setupLog.Info("Ignoring Non-Positive Integer", "Variable", key, "Value", value)
+		return defaultValue
+	}
+
+	return value
+}
+
+// lookupBoolEnv returns the boolean set in the given environment variable or the default value when unset or invalid
+func lookupBoolEnv(key string, defaultValue bool) bool {
+
+	value, ok := os.LookupEnv(key)
+
+	if ok {
+		valueBool, err := strconv.ParseBool(value)
+
+		if err == nil {
+			return valueBool
+		}
+
+		setupLog.Info("Ignoring Invalid Boolean", "Variable", key, "Value", value)
+	}
+
+	return defaultValue
+}
+
+// lookupStringEnv returns the value set in the given environment variable or the default value when unset or empty
+func lookupStringEnv(key string, defaultValue string) string {
+
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return defaultValue
+}
+
+// lookupChartCleanupPolicy returns the chart cleanup policy set in the given environment variable, defaulting to Delete
+func lookupChartCleanupPolicy(key string) controllers.ChartCleanupPolicy {
+
+	policy, ok := os.LookupEnv(key)
+
+	if ok {
+		switch controllers.ChartCleanupPolicy(policy) {
+		case controllers.DeleteChartCleanupPolicy, controllers.RetainChartCleanupPolicy:
+			return controllers.ChartCleanupPolicy(policy)
+		default:
+			setupLog.Info("Ignoring Invalid Chart Cleanup Policy", "Variable", key, "Policy", policy)
+		}
+	}
+
+	return controllers.DeleteChartCleanupPolicy
+}
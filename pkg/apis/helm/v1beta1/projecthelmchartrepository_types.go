@@ -0,0 +1,92 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	configv1 "github.com/openshift/api/config/v1"
+	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProjectHelmChartRepositorySpec defines a Helm chart repository exposed within a namespace
+type ProjectHelmChartRepositorySpec struct {
+
+	// If set to true, disable the repo usage in the namespace
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Optional associated human readable repository name, it can be used by UI for displaying purposes
+	// +optional
+	DisplayName string `json:"name,omitempty"`
+
+	// Optional human readable repository description, it can be used by UI for displaying purposes
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Required configuration for connecting to the chart repo
+	ProjectConnectionConfig ConnectionConfigNamespaceScoped `json:"connectionConfig"`
+}
+
+// ConnectionConfigNamespaceScoped defines the connection to a project scoped repository. The referenced ConfigMaps and
+// Secrets are located in the namespace of the repository
+type ConnectionConfigNamespaceScoped struct {
+
+	// Chart repository URL
+	URL string `json:"url"`
+
+	// ca is an optional reference to a config map by name containing the PEM-encoded CA bundle.
+	// The key "ca-bundle.crt" is used to locate the data.
+	// +optional
+	CA configv1.ConfigMapNameReference `json:"ca,omitempty"`
+
+	// tlsClientConfig is an optional reference to a secret by name that contains the
+	// PEM-encoded TLS client certificate and private key to present when connecting to the server.
+	// The key "tls.crt" is used to locate the client certificate.
+	// The key "tls.key" is used to locate the private key.
+	// +optional
+	TLSClientConfig configv1.SecretNameReference `json:"tlsClientConfig,omitempty"`
+
+	// basicAuthConfig is an optional reference to a secret by name that contains
+	// the basic authentication credentials to present when connecting to the server.
+	// The key "username" is used locate the username.
+	// The key "password" is used to locate the password.
+	// +optional
+	BasicAuthConfig configv1.SecretNameReference `json:"basicAuthConfig,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProjectHelmChartRepository holds namespace-wide configuration for proxied Helm chart repository
+type ProjectHelmChartRepository struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProjectHelmChartRepositorySpec        `json:"spec"`
+	Status helmv1beta1.HelmChartRepositoryStatus `json:"status"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProjectHelmChartRepositoryList contains a list of ProjectHelmChartRepository
+type ProjectHelmChartRepositoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProjectHelmChartRepository `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProjectHelmChartRepository{}, &ProjectHelmChartRepositoryList{})
+}
@@ -0,0 +1,267 @@
+package filter
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ChartFilter selects the chart versions synchronized from a repository using the rules of the HelmChartFilters
+// that apply to the repository. A chart version is synchronized when it matches any include rule, or no include rules
+// are specified, and matches no exclude rule
+type ChartFilter struct {
+	include []*rule
+	exclude []*rule
+	hash    string
+}
+
+// rule is the compiled form of a HelmChartFilterRule
+type rule struct {
+	name       string
+	keyword    string
+	chartType  string
+	maintainer string
+	versions   *semver.Constraints
+}
+
+// Selects determines whether the filter applies to a repository with the given labels
+func Selects(helmChartFilter *redhatcopv1alpha1.HelmChartFilter, repositoryLabels map[string]string) (bool, error) {
+
+	if helmChartFilter.Spec.RepositorySelector == nil {
+		return true, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(helmChartFilter.Spec.RepositorySelector)
+
+	if err != nil {
+		return false, fmt.Errorf("Invalid repository selector of filter %s: %v", helmChartFilter.Name, err)
+	}
+
+	return selector.Matches(labels.Set(repositoryLabels)), nil
+}
+
+// New compiles the rules of the filters. Nil is returned when no filters are given, which selects all chart versions
+func New(helmChartFilters []redhatcopv1alpha1.HelmChartFilter) (*ChartFilter, error) {
+
+	if len(helmChartFilters) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(helmChartFilters, func(i, j int) bool {
+		return helmChartFilters[i].Name < helmChartFilters[j].Name
+	})
+
+	chartFilter := &ChartFilter{}
+	specs := []redhatcopv1alpha1.HelmChartFilterSpec{}
+
+	for _, helmChartFilter := range helmChartFilters {
+
+		for _, filterRule := range helmChartFilter.Spec.Include {
+			compiledRule, err := compileRule(&filterRule)
+
+			if err != nil {
+				return nil, fmt.Errorf("Invalid include rule of filter %s: %v", helmChartFilter.Name, err)
+			}
+
+			chartFilter.include = append(chartFilter.include, compiledRule)
+		}
+
+		for _, filterRule := range helmChartFilter.Spec.Exclude {
+			compiledRule, err := compileRule(&filterRule)
+
+			if err != nil {
+				return nil, fmt.Errorf("Invalid exclude rule of filter %s: %v", helmChartFilter.Name, err)
+			}
+
+			chartFilter.exclude = append(chartFilter.exclude, compiledRule)
+		}
+
+		specs = append(specs, helmChartFilter.Spec)
+	}
+
+	specBytes, err := json.Marshal(specs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	chartFilter.hash = fmt.Sprintf("%x", sha256.Sum256(specBytes))
+
+	return chartFilter, nil
+}
+
+func compileRule(filterRule *redhatcopv1alpha1.HelmChartFilterRule) (*rule, error) {
+
+	compiledRule := &rule{
+		name:       filterRule.Name,
+		keyword:    strings.ToLower(filterRule.Keyword),
+		chartType:  filterRule.Type,
+		maintainer: strings.ToLower(filterRule.Maintainer),
+	}
+
+	// Patterns are validated up front as path.Match only reports malformed patterns when they are evaluated
+	if _, err := path.Match(compiledRule.name, ""); err != nil {
+		return nil, fmt.Errorf("invalid name pattern %q: %v", filterRule.Name, err)
+	}
+
+	if _, err := path.Match(compiledRule.maintainer, ""); err != nil {
+		return nil, fmt.Errorf("invalid maintainer pattern %q: %v", filterRule.Maintainer, err)
+	}
+
+	if filterRule.Versions != "" {
+		constraints, err := semver.NewConstraint(filterRule.Versions)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %v", filterRule.Versions, err)
+		}
+
+		compiledRule.versions = constraints
+	}
+
+	return compiledRule, nil
+}
+
+// Hash returns a hash of the rules of the filter, allowing changes to the rules to be detected
+func (f *ChartFilter) Hash() string {
+
+	if f == nil {
+		return ""
+	}
+
+	return f.hash
+}
+
+// Filter returns the versions of the chart selected by the filter
+func (f *ChartFilter) Filter(chartName string, chartVersions repo.ChartVersions) repo.ChartVersions {
+
+	if f == nil {
+		return chartVersions
+	}
+
+	selectedVersions := repo.ChartVersions{}
+
+	for _, chartVersion := range chartVersions {
+		if f.Matches(chartName, chartVersion) {
+			selectedVersions = append(selectedVersions, chartVersion)
+		}
+	}
+
+	return selectedVersions
+}
+
+// Matches determines whether the filter selects the version of the chart
+func (f *ChartFilter) Matches(chartName string, chartVersion *repo.ChartVersion) bool {
+
+	if f == nil {
+		return true
+	}
+
+	included := len(f.include) == 0
+
+	for _, includeRule := range f.include {
+		if includeRule.matches(chartName, chartVersion) {
+			included = true
+			break
+		}
+	}
+
+	if !included {
+		return false
+	}
+
+	for _, excludeRule := range f.exclude {
+		if excludeRule.matches(chartName, chartVersion) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *rule) matches(chartName string, chartVersion *repo.ChartVersion) bool {
+
+	if r.name != "" {
+		if matched, _ := path.Match(r.name, chartName); !matched {
+			return false
+		}
+	}
+
+	metadata := chartVersion.Metadata
+
+	if metadata == nil {
+		metadata = &chart.Metadata{}
+	}
+
+	if r.keyword != "" && !containsKeyword(metadata.Keywords, r.keyword) {
+		return false
+	}
+
+	if r.chartType != "" {
+		// Charts without a type are treated as application charts by Helm
+		chartType := metadata.Type
+
+		if chartType == "" {
+			chartType = "application"
+		}
+
+		if chartType != r.chartType {
+			return false
+		}
+	}
+
+	if r.maintainer != "" && !matchesMaintainer(metadata.Maintainers, r.maintainer) {
+		return false
+	}
+
+	if r.versions != nil {
+		version, err := semver.NewVersion(metadata.Version)
+
+		if err != nil || !r.versions.Check(version) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsKeyword determines whether the keywords contain the keyword, ignoring case
+func containsKeyword(keywords []string, keyword string) bool {
+
+	for _, k := range keywords {
+		if strings.ToLower(k) == keyword {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesMaintainer determines whether the name or email of any maintainer matches the pattern, ignoring case
+func matchesMaintainer(maintainers []*chart.Maintainer, pattern string) bool {
+
+	for _, maintainer := range maintainers {
+		if maintainer == nil {
+			continue
+		}
+
+		if matched, _ := path.Match(pattern, strings.ToLower(maintainer.Name)); matched {
+			return true
+		}
+
+		if matched, _ := path.Match(pattern, strings.ToLower(maintainer.Email)); matched {
+			return true
+		}
+	}
+
+	return false
+}
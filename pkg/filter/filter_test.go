@@ -0,0 +1,252 @@
+package filter
+
+import (
+	"testing"
+
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newChartVersion(version string, chartType string, keywords []string, maintainers ...*chart.Maintainer) *repo.ChartVersion {
+	return &repo.ChartVersion{
+		Metadata: &chart.Metadata{
+			Version:     version,
+			Type:        chartType,
+			Keywords:    keywords,
+			Maintainers: maintainers,
+		},
+	}
+}
+
+func newChartFilter(name string, include []redhatcopv1alpha1.HelmChartFilterRule, exclude []redhatcopv1alpha1.HelmChartFilterRule) redhatcopv1alpha1.HelmChartFilter {
+	return redhatcopv1alpha1.HelmChartFilter{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: redhatcopv1alpha1.HelmChartFilterSpec{
+			Include: include,
+			Exclude: exclude,
+		},
+	}
+}
+
+func TestMatches(t *testing.T) {
+
+	nginx := newChartVersion("1.2.3", "", []string{"Web", "proxy"}, &chart.Maintainer{Name: "Jane Doe", Email: "jane@example.com"})
+	library := newChartVersion("0.1.0", "library", nil)
+
+	tests := []struct {
+		name         string
+		filters      []redhatcopv1alpha1.HelmChartFilter
+		chartName    string
+		chartVersion *repo.ChartVersion
+		expected     bool
+	}{
+		{
+			name:         "no filters",
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     true,
+		},
+		{
+			name:         "name pattern",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Name: "ngin*"}}, nil)},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     true,
+		},
+		{
+			name:         "name pattern not matching",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Name: "redis*"}}, nil)},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     false,
+		},
+		{
+			name:         "keyword ignoring case",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Keyword: "web"}}, nil)},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     true,
+		},
+		{
+			name:         "charts without a type are application charts",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Type: "application"}}, nil)},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     true,
+		},
+		{
+			name:         "type not matching",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Type: "application"}}, nil)},
+			chartName:    "common",
+			chartVersion: library,
+			expected:     false,
+		},
+		{
+			name:         "maintainer email pattern",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Maintainer: "*@example.com"}}, nil)},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     true,
+		},
+		{
+			name:         "version range",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Versions: ">= 1.0.0, < 2.0.0"}}, nil)},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     true,
+		},
+		{
+			name:         "version range not matching",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Versions: ">= 2.0.0"}}, nil)},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     false,
+		},
+		{
+			name:         "all fields of a rule must match",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Name: "nginx", Keyword: "database"}}, nil)},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     false,
+		},
+		{
+			name: "any include rule of any filter matches",
+			filters: []redhatcopv1alpha1.HelmChartFilter{
+				newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Name: "redis"}}, nil),
+				newChartFilter("b", []redhatcopv1alpha1.HelmChartFilterRule{{Name: "nginx"}}, nil),
+			},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     true,
+		},
+		{
+			name:         "exclude rule without include rules",
+			filters:      []redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", nil, []redhatcopv1alpha1.HelmChartFilterRule{{Type: "library"}})},
+			chartName:    "common",
+			chartVersion: library,
+			expected:     false,
+		},
+		{
+			name: "exclude rule takes precedence over include rules",
+			filters: []redhatcopv1alpha1.HelmChartFilter{
+				newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{{Name: "nginx"}}, nil),
+				newChartFilter("b", nil, []redhatcopv1alpha1.HelmChartFilterRule{{Versions: "< 2.0.0"}}),
+			},
+			chartName:    "nginx",
+			chartVersion: nginx,
+			expected:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			chartFilter, err := New(test.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if matched := chartFilter.Matches(test.chartName, test.chartVersion); matched != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, matched)
+			}
+		})
+	}
+}
+
+func TestNewInvalidRules(t *testing.T) {
+
+	tests := []struct {
+		name string
+		rule redhatcopv1alpha1.HelmChartFilterRule
+	}{
+		{name: "name pattern", rule: redhatcopv1alpha1.HelmChartFilterRule{Name: "nginx-["}},
+		{name: "maintainer pattern", rule: redhatcopv1alpha1.HelmChartFilterRule{Maintainer: "["}},
+		{name: "version range", rule: redhatcopv1alpha1.HelmChartFilterRule{Versions: "not a range"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			_, err := New([]redhatcopv1alpha1.HelmChartFilter{newChartFilter("a", []redhatcopv1alpha1.HelmChartFilterRule{test.rule}, nil)})
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+
+	first, err := New([]redhatcopv1alpha1.HelmChartFilter{
+		newChartFilter("b", []redhatcopv1alpha1.HelmChartFilterRule{{Name: "nginx"}}, nil),
+		newChartFilter("a", nil, []redhatcopv1alpha1.HelmChartFilterRule{{Type: "library"}}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := New([]redhatcopv1alpha1.HelmChartFilter{
+		newChartFilter("a", nil, []redhatcopv1alpha1.HelmChartFilterRule{{Type: "library"}}),
+		newChartFilter("b", []redhatcopv1alpha1.HelmChartFilterRule{{Name: "nginx"}}, nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Hash() != second.Hash() {
+		t.Error("expected the hash not to depend on the order of the filters")
+	}
+
+	var none *ChartFilter
+	if none.Hash() != "" {
+		t.Error("expected an empty hash without filters")
+	}
+}
+
+func TestSelects(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		repositorySelector *metav1.LabelSelector
+		expected           bool
+		expectError        bool
+	}{
+		{name: "no selector", expected: true},
+		{name: "matching selector", repositorySelector: &metav1.LabelSelector{MatchLabels: map[string]string{"catalog": "bitnami"}}, expected: true},
+		{name: "selector not matching", repositorySelector: &metav1.LabelSelector{MatchLabels: map[string]string{"catalog": "curated"}}, expected: false},
+		{
+			name: "invalid selector",
+			repositorySelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "catalog", Operator: "Matches"},
+			}},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			helmChartFilter := newChartFilter("a", nil, nil)
+			helmChartFilter.Spec.RepositorySelector = test.repositorySelector
+
+			selected, err := Selects(&helmChartFilter, map[string]string{"catalog": "bitnami"})
+
+			if test.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if selected != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, selected)
+			}
+		})
+	}
+}
@@ -0,0 +1,662 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const (
+	// Scheme is the URL scheme identifying OCI registries
+	Scheme = "oci"
+
+	// HelmChartConfigMediaType is the media type of the config blob of a Helm chart manifest
+	HelmChartConfigMediaType = "application/vnd.cncf.helm.config.v1+json"
+
+	manifestMediaType  = "application/vnd.oci.image.manifest.v1+json"
+	createdAnnotation  = "org.opencontainers.image.created"
+	catalogPageSize    = 1000
+	maxBlobSize        = 4 * 1024 * 1024
+	maxListingPageSize = 16 * 1024 * 1024
+)
+
+// ErrCatalogUnsupported is returned when the registry does not support enumerating repositories
+var ErrCatalogUnsupported = errors.New("registry does not support the catalog API")
+
+// ErrIndexTooLarge is returned when the combined size of the chart metadata exceeds the maximum index size
+var ErrIndexTooLarge = errors.New("chart metadata exceeds the maximum index size")
+
+// ErrInvalidMetadata is returned when the metadata of a chart stored within the registry cannot be parsed
+var ErrInvalidMetadata = errors.New("invalid chart metadata")
+
+// StatusError is returned when the registry responds with an unexpected status code
+type StatusError struct {
+	Path       string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Response for %s returned status code %d", e.Path, e.StatusCode)
+}
+
+// Credentials represents the credentials used to authenticate against a registry
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// Client enumerates Helm charts stored within an OCI registry using the distribution API
+type Client struct {
+	httpClient  *http.Client
+	baseURL     *url.URL
+	host        string
+	prefix      string
+	credentials *Credentials
+	maxSize     int64
+
+	tokenMutex sync.Mutex
+	tokens     map[string]string
+}
+
+// Manifest represents the content of a chart manifest
+type Manifest struct {
+	Repository string
+	Tag        string
+	Digest     string
+	Config     Descriptor
+	Layers     []Descriptor
+	Created    time.Time
+}
+
+// Descriptor references a blob within a registry
+type Descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type manifestContent struct {
+	Config      Descriptor        `json:"config"`
+	Layers      []Descriptor      `json:"layers"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NewClient returns a client for the registry referenced by an oci:// URL. The path of the URL limits the registry
+// repositories that are enumerated. When plainHTTP is set the registry is accessed without TLS. maxSize limits the
+// combined size of the chart metadata retrieved to build an index, which is unlimited when zero
+func NewClient(httpClient *http.Client, registryURL string, credentials *Credentials, plainHTTP bool, maxSize int64) (*Client, error) {
+
+	parsedURL, err := url.Parse(registryURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedURL.Scheme != Scheme {
+		return nil, fmt.Errorf("URL %s is not an OCI registry URL", registryURL)
+	}
+
+	scheme := "https"
+
+	if plainHTTP {
+		scheme = "http"
+	}
+
+	return &Client{
+		httpClient:  httpClient,
+		baseURL:     &url.URL{Scheme: scheme, Host: parsedURL.Host},
+		host:        parsedURL.Host,
+		prefix:      strings.Trim(parsedURL.Path, "/"),
+		credentials: credentials,
+		maxSize:     maxSize,
+		tokens:      map[string]string{},
+	}, nil
+}
+
+// ListManifests returns the manifests of every tag within the registry repositories matching the URL path, including
+// artifacts other than Helm charts so they are not retrieved again by later listings. When the registry does not
+// support the catalog API, the URL path is treated as a single repository. The known manifests of a previous listing
+// are revalidated using HEAD requests and only retrieved again when their digest changed
+func (c *Client) ListManifests(ctx context.Context, knownManifests []*Manifest) ([]*Manifest, error) {
+
+	known := map[string]*Manifest{}
+
+	for _, manifest := range knownManifests {
+		known[manifest.Repository+":"+manifest.Tag] = manifest
+	}
+
+	repositories, err := c.listRepositories(ctx)
+
+	if errors.Is(err, ErrCatalogUnsupported) && c.prefix != "" {
+		repositories = []string{c.prefix}
+	} else if err != nil {
+		return nil, err
+	}
+
+	manifests := []*Manifest{}
+
+	for _, repository := range repositories {
+
+		tags, err := c.listTags(ctx, repository)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range tags {
+
+			manifest, found := known[repository+":"+tag]
+
+			if found {
+				digest, err := c.getManifestDigest(ctx, repository, tag)
+
+				if err != nil {
+					return nil, err
+				}
+
+				found = digest == manifest.Digest
+			}
+
+			if !found {
+				manifest, err = c.getManifest(ctx, repository, tag)
+
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			manifests = append(manifests, manifest)
+		}
+	}
+
+	return manifests, nil
+}
+
+// IsChart determines whether the manifest represents a Helm chart rather than another artifact
+func (m *Manifest) IsChart() bool {
+	return m.Config.MediaType == HelmChartConfigMediaType
+}
+
+// Charts returns the manifests representing Helm charts
+func Charts(manifests []*Manifest) []*Manifest {
+
+	charts := []*Manifest{}
+
+	for _, manifest := range manifests {
+		if manifest.IsChart() {
+			charts = append(charts, manifest)
+		}
+	}
+
+	return charts
+}
+
+// Digest returns a digest identifying the given set of manifests
+func Digest(manifests []*Manifest) string {
+
+	references := []string{}
+
+	for _, manifest := range manifests {
+		references = append(references, fmt.Sprintf("%s:%s@%s", manifest.Repository, manifest.Tag, manifest.Digest))
+	}
+
+	sort.Strings(references)
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(references, "\n"))))
+}
+
+// IndexFile reads the chart metadata of each manifest and returns an index containing the charts
+func (c *Client) IndexFile(ctx context.Context, manifests []*Manifest) (*repo.IndexFile, error) {
+
+	var size int64
+
+	for _, manifest := range manifests {
+		size += manifest.Config.Size
+	}
+
+	// The size of the chart metadata is verified before it is retrieved
+	if c.maxSize > 0 && size > c.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceed the maximum of %d bytes", ErrIndexTooLarge, size, c.maxSize)
+	}
+
+	indexFile := repo.NewIndexFile()
+
+	for _, manifest := range manifests {
+
+		blob, err := c.get(ctx, manifest.Repository, fmt.Sprintf("/v2/%s/blobs/%s", manifest.Repository, manifest.Config.Digest), "", maxBlobSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		metadata := &chart.Metadata{}
+
+		err = json.Unmarshal(blob, metadata)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w of %s:%s: %v", ErrInvalidMetadata, manifest.Repository, manifest.Tag, err)
+		}
+
+		if metadata.Name == "" {
+			metadata.Name = path.Base(manifest.Repository)
+		}
+
+		if metadata.Version == "" {
+			metadata.Version = strings.ReplaceAll(manifest.Tag, "_", "+")
+		}
+
+		chartVersion := &repo.ChartVersion{
+			Metadata: metadata,
+			URLs:     []string{fmt.Sprintf("%s://%s/%s:%s", Scheme, c.host, manifest.Repository, manifest.Tag)},
+			Created:  manifest.Created,
+		}
+
+		for _, layer := range manifest.Layers {
+			if layer.MediaType != HelmChartConfigMediaType {
+				chartVersion.Digest = strings.TrimPrefix(layer.Digest, "sha256:")
+				break
+			}
+		}
+
+		indexFile.Entries[metadata.Name] = append(indexFile.Entries[metadata.Name], chartVersion)
+	}
+
+	indexFile.SortEntries()
+
+	return indexFile, nil
+}
+
+func (c *Client) listRepositories(ctx context.Context) ([]string, error) {
+
+	repositories := []string{}
+	next := fmt.Sprintf("/v2/_catalog?n=%d", catalogPageSize)
+
+	for next != "" {
+
+		resp, err := c.do(ctx, http.MethodGet, "registry:catalog:*", next, "")
+
+		// Authorization servers may refuse the catalog scope when the registry does not permit enumerating repositories
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden) {
+			return nil, ErrCatalogUnsupported
+		} else if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			resp.Body.Close()
+			return nil, ErrCatalogUnsupported
+		}
+
+		catalog := struct {
+			Repositories []string `json:"repositories"`
+		}{}
+
+		err = decode(resp, &catalog)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repository := range catalog.Repositories {
+			if c.prefix == "" || repository == c.prefix || strings.HasPrefix(repository, c.prefix+"/") {
+				repositories = append(repositories, repository)
+			}
+		}
+
+		next = nextLink(resp)
+	}
+
+	return repositories, nil
+}
+
+func (c *Client) listTags(ctx context.Context, repository string) ([]string, error) {
+
+	tags := []string{}
+	next := fmt.Sprintf("/v2/%s/tags/list", repository)
+
+	for next != "" {
+
+		resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("repository:%s:pull", repository), next, "")
+
+		if err != nil {
+			return nil, err
+		}
+
+		tagList := struct {
+			Tags []string `json:"tags"`
+		}{}
+
+		err = decode(resp, &tagList)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tagList.Tags...)
+		next = nextLink(resp)
+	}
+
+	return tags, nil
+}
+
+func (c *Client) getManifest(ctx context.Context, repository string, tag string) (*Manifest, error) {
+
+	body, err := c.get(ctx, repository, fmt.Sprintf("/v2/%s/manifests/%s", repository, tag), manifestMediaType, maxBlobSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	content := &manifestContent{}
+
+	err = json.Unmarshal(body, content)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse manifest of %s:%s: %v", repository, tag, err)
+	}
+
+	manifest := &Manifest{
+		Repository: repository,
+		Tag:        tag,
+		Digest:     fmt.Sprintf("sha256:%x", sha256.Sum256(body)),
+		Config:     content.Config,
+		Layers:     content.Layers,
+	}
+
+	if created, found := content.Annotations[createdAnnotation]; found {
+		if createdTime, err := time.Parse(time.RFC3339, created); err == nil {
+			manifest.Created = createdTime
+		}
+	}
+
+	return manifest, nil
+}
+
+// getManifestDigest returns the digest of the manifest of a tag without retrieving the manifest. An empty digest is
+// returned when the registry does not report the digest
+func (c *Client) getManifestDigest(ctx context.Context, repository string, tag string) (string, error) {
+
+	requestPath := fmt.Sprintf("/v2/%s/manifests/%s", repository, tag)
+
+	resp, err := c.do(ctx, http.MethodHead, fmt.Sprintf("repository:%s:pull", repository), requestPath, manifestMediaType)
+
+	if err != nil {
+		return "", err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{Path: requestPath, StatusCode: resp.StatusCode}
+	}
+
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// get retrieves the content of the given path within the registry, limited to maxSize bytes
+func (c *Client) get(ctx context.Context, repository string, requestPath string, accept string, maxSize int64) ([]byte, error) {
+
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("repository:%s:pull", repository), requestPath, accept)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Path: requestPath, StatusCode: resp.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("Response for %s exceeds the maximum size of %d bytes", requestPath, maxSize)
+	}
+
+	return body, nil
+}
+
+// do sends a request to the registry, authenticating using the token flow of the distribution API when challenged
+func (c *Client) do(ctx context.Context, method string, scope string, requestPath string, accept string) (*http.Response, error) {
+
+	requestURL, err := c.baseURL.Parse(requestPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		c.authorize(req, scope)
+
+		return c.httpClient.Do(req)
+	}
+
+	resp, err := send()
+
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	err = c.authenticate(ctx, scope, challenge)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return send()
+}
+
+// authorize adds the token obtained for the scope or the configured credentials to the request
+func (c *Client) authorize(req *http.Request, scope string) {
+
+	c.tokenMutex.Lock()
+	token, found := c.tokens[scope]
+	c.tokenMutex.Unlock()
+
+	if found {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+
+	if c.credentials == nil {
+		return
+	}
+
+	if c.credentials.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.credentials.Token)
+	} else {
+		req.SetBasicAuth(c.credentials.Username, c.credentials.Password)
+	}
+}
+
+// authenticate obtains a token for the scope from the authorization server referenced by a Bearer challenge
+func (c *Client) authenticate(ctx context.Context, scope string, challenge string) error {
+
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return fmt.Errorf("Registry %s requires unsupported authentication %q", c.host, challenge)
+	}
+
+	parameters := parseChallenge(challenge[len("bearer "):])
+
+	realm, err := url.Parse(parameters["realm"])
+
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("Registry %s returned invalid authentication realm %q", c.host, parameters["realm"])
+	}
+
+	query := realm.Query()
+
+	if service, found := parameters["service"]; found {
+		query.Set("service", service)
+	}
+
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	if c.credentials != nil && c.credentials.Token == "" {
+		req.SetBasicAuth(c.credentials.Username, c.credentials.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	tokenResponse := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	err = decode(resp, &tokenResponse)
+
+	if err != nil {
+		return fmt.Errorf("Failed to obtain token from %s: %w", realm.Host, err)
+	}
+
+	token := tokenResponse.Token
+
+	if token == "" {
+		token = tokenResponse.AccessToken
+	}
+
+	if token == "" {
+		return fmt.Errorf("Authorization server %s did not return a token", realm.Host)
+	}
+
+	c.tokenMutex.Lock()
+	c.tokens[scope] = token
+	c.tokenMutex.Unlock()
+
+	return nil
+}
+
+// parseChallenge parses the comma separated key=value parameters of an authentication challenge. Quoted values can
+// contain commas, such as the scope "repository:charts/nginx:pull,push", and backslash escaped characters
+func parseChallenge(parameters string) map[string]string {
+
+	result := map[string]string{}
+
+	for i := 0; i < len(parameters); {
+
+		// Parameters are separated by commas and optional whitespace
+		for i < len(parameters) && (parameters[i] == ',' || parameters[i] == ' ' || parameters[i] == '\t') {
+			i++
+		}
+
+		keyStart := i
+		for i < len(parameters) && parameters[i] != '=' && parameters[i] != ',' {
+			i++
+		}
+
+		key := strings.ToLower(strings.TrimSpace(parameters[keyStart:i]))
+
+		// Parameters without a value are ignored
+		if i >= len(parameters) || parameters[i] != '=' {
+			continue
+		}
+
+		i++
+
+		for i < len(parameters) && (parameters[i] == ' ' || parameters[i] == '\t') {
+			i++
+		}
+
+		var value strings.Builder
+
+		if i < len(parameters) && parameters[i] == '"' {
+			for i++; i < len(parameters) && parameters[i] != '"'; i++ {
+				if parameters[i] == '\\' && i+1 < len(parameters) {
+					i++
+				}
+				value.WriteByte(parameters[i])
+			}
+
+			// Skip the closing quote
+			i++
+		} else {
+			valueStart := i
+			for i < len(parameters) && parameters[i] != ',' {
+				i++
+			}
+			value.WriteString(strings.TrimSpace(parameters[valueStart:i]))
+		}
+
+		if key != "" {
+			result[key] = value.String()
+		}
+	}
+
+	return result
+}
+
+// nextLink returns the path of the next page referenced by the Link header of a paginated response
+func nextLink(resp *http.Response) string {
+
+	link := resp.Header.Get("Link")
+
+	if link == "" || !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+
+	start := strings.Index(link, "<")
+	end := strings.Index(link, ">")
+
+	if start < 0 || end <= start {
+		return ""
+	}
+
+	return link[start+1 : end]
+}
+
+// decode closes the response after parsing the JSON content of a successful response
+func decode(resp *http.Response, v interface{}) error {
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{Path: resp.Request.URL.Path, StatusCode: resp.StatusCode}
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxListingPageSize)).Decode(v)
+}
@@ -0,0 +1,294 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testRegistry is a registry stand-in serving the charts of a single repository behind the token flow of the
+// distribution API
+type testRegistry struct {
+	server      *httptest.Server
+	repository  string
+	tags        []string
+	denyCatalog bool
+
+	mutex    sync.Mutex
+	requests map[string]int
+}
+
+func newTestRegistry(t *testing.T, repository string, tags ...string) *testRegistry {
+
+	registry := &testRegistry{
+		repository: repository,
+		tags:       tags,
+		requests:   map[string]int{},
+	}
+
+	registry.server = httptest.NewServer(http.HandlerFunc(registry.serveHTTP))
+	t.Cleanup(registry.server.Close)
+
+	return registry
+}
+
+func (r *testRegistry) url() string {
+	return fmt.Sprintf("%s://%s/%s", Scheme, strings.TrimPrefix(r.server.URL, "http://"), r.repository)
+}
+
+func (r *testRegistry) count(method string, kind string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.requests[method+" "+kind]
+}
+
+func (r *testRegistry) config(tag string) []byte {
+	config, _ := json.Marshal(map[string]string{"apiVersion": "v2", "name": "nginx", "version": tag})
+	return config
+}
+
+func (r *testRegistry) manifest(tag string) []byte {
+	config := r.config(tag)
+
+	// Tags prefixed with sha256- represent artifacts other than charts, such as signatures
+	mediaType := HelmChartConfigMediaType
+	if strings.HasPrefix(tag, "sha256-") {
+		mediaType = "application/vnd.oci.image.config.v1+json"
+	}
+
+	manifest, _ := json.Marshal(manifestContent{
+		Config: Descriptor{MediaType: mediaType, Digest: fmt.Sprintf("sha256:%x", sha256.Sum256(config)), Size: int64(len(config))},
+		Layers: []Descriptor{{MediaType: "application/vnd.cncf.helm.chart.content.v1.tar+gzip", Digest: "sha256:" + tag}},
+	})
+	return manifest
+}
+
+func (r *testRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+
+	if req.URL.Path == "/token" {
+		scope := req.URL.Query().Get("scope")
+
+		if r.denyCatalog && strings.HasPrefix(scope, "registry:catalog") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"token": "token-" + scope})
+		return
+	}
+
+	scope := fmt.Sprintf("repository:%s:pull", r.repository)
+	kind := ""
+
+	switch {
+	case req.URL.Path == "/v2/_catalog":
+		scope, kind = "registry:catalog:*", "catalog"
+	case req.URL.Path == fmt.Sprintf("/v2/%s/tags/list", r.repository):
+		kind = "tags"
+	case strings.HasPrefix(req.URL.Path, fmt.Sprintf("/v2/%s/manifests/", r.repository)):
+		kind = "manifest"
+	case strings.HasPrefix(req.URL.Path, fmt.Sprintf("/v2/%s/blobs/", r.repository)):
+		kind = "blob"
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if req.Header.Get("Authorization") != "Bearer token-"+scope {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, r.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.mutex.Lock()
+	r.requests[req.Method+" "+kind]++
+	r.mutex.Unlock()
+
+	switch kind {
+	case "catalog":
+		json.NewEncoder(w).Encode(map[string][]string{"repositories": {r.repository, "other/chart"}})
+	case "tags":
+		json.NewEncoder(w).Encode(map[string][]string{"tags": r.tags})
+	case "manifest":
+		manifest := r.manifest(req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:])
+		w.Header().Set("Docker-Content-Digest", fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)))
+		w.Write(manifest)
+	case "blob":
+		for _, tag := range r.tags {
+			if config := r.config(tag); strings.HasSuffix(req.URL.Path, fmt.Sprintf("%x", sha256.Sum256(config))) {
+				w.Write(config)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestIndexFile(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		denyCatalog bool
+	}{
+		{name: "catalog", denyCatalog: false},
+		{name: "catalog denied by the authorization server", denyCatalog: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			registry := newTestRegistry(t, "charts/nginx", "1.0.0", "1.1.0", "sha256-1234.sig")
+			registry.denyCatalog = test.denyCatalog
+
+			client, err := NewClient(http.DefaultClient, registry.url(), nil, true, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			manifests, err := client.ListManifests(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(manifests) != 3 {
+				t.Fatalf("expected 3 manifests, got %d", len(manifests))
+			}
+
+			charts := Charts(manifests)
+
+			if len(charts) != 2 {
+				t.Fatalf("expected 2 charts, got %d", len(charts))
+			}
+
+			indexFile, err := client.IndexFile(context.Background(), charts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			versions := indexFile.Entries["nginx"]
+
+			if len(versions) != 2 || versions[0].Version != "1.1.0" || versions[1].Version != "1.0.0" {
+				t.Fatalf("unexpected chart versions %v", versions)
+			}
+
+			if expected := fmt.Sprintf("%s:1.1.0", registry.url()); versions[0].URLs[0] != expected {
+				t.Errorf("expected URL %s, got %s", expected, versions[0].URLs[0])
+			}
+		})
+	}
+}
+
+func TestListManifestsRevalidatesKnownManifests(t *testing.T) {
+
+	registry := newTestRegistry(t, "charts/nginx", "1.0.0", "1.1.0", "sha256-1234.sig")
+
+	client, err := NewClient(http.DefaultClient, registry.url(), nil, true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifests, err := client.ListManifests(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revalidated, err := client.ListManifests(context.Background(), manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Digest(revalidated) != Digest(manifests) {
+		t.Error("expected the revalidated manifests to match")
+	}
+
+	// Manifests of artifacts other than charts are revalidated as well rather than retrieved again
+	if count := registry.count(http.MethodGet, "manifest"); count != 3 {
+		t.Errorf("expected 3 manifest retrievals, got %d", count)
+	}
+
+	if count := registry.count(http.MethodHead, "manifest"); count != 3 {
+		t.Errorf("expected 3 manifest revalidations, got %d", count)
+	}
+}
+
+func TestIndexFileMaxSize(t *testing.T) {
+
+	registry := newTestRegistry(t, "charts/nginx", "1.0.0", "1.1.0")
+
+	client, err := NewClient(http.DefaultClient, registry.url(), nil, true, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifests, err := client.ListManifests(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.IndexFile(context.Background(), manifests)
+
+	if !errors.Is(err, ErrIndexTooLarge) {
+		t.Fatalf("expected ErrIndexTooLarge, got %v", err)
+	}
+
+	if count := registry.count(http.MethodGet, "blob"); count != 0 {
+		t.Errorf("expected no blob retrievals, got %d", count)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		parameters string
+		expected   map[string]string
+	}{
+		{
+			name:       "realm and service",
+			parameters: `realm="https://auth.example.com/token",service="registry.example.com"`,
+			expected:   map[string]string{"realm": "https://auth.example.com/token", "service": "registry.example.com"},
+		},
+		{
+			name:       "scope containing commas",
+			parameters: `realm="https://auth.example.com/token",service="registry.example.com",scope="repository:charts/nginx:pull,push"`,
+			expected:   map[string]string{"realm": "https://auth.example.com/token", "service": "registry.example.com", "scope": "repository:charts/nginx:pull,push"},
+		},
+		{
+			name:       "scope containing commas before the realm",
+			parameters: `scope="repository:charts/nginx:pull,push", realm="https://auth.example.com/token"`,
+			expected:   map[string]string{"scope": "repository:charts/nginx:pull,push", "realm": "https://auth.example.com/token"},
+		},
+		{
+			name:       "unquoted values and uppercase keys",
+			parameters: `Realm=https://auth.example.com/token, Service=registry.example.com`,
+			expected:   map[string]string{"realm": "https://auth.example.com/token", "service": "registry.example.com"},
+		},
+		{
+			name:       "escaped quotes",
+			parameters: `realm="https://auth.example.com/token",error="invalid \"token\", retry"`,
+			expected:   map[string]string{"realm": "https://auth.example.com/token", "error": `invalid "token", retry`},
+		},
+		{
+			name:       "parameters without values",
+			parameters: `realm="https://auth.example.com/token",invalid,,`,
+			expected:   map[string]string{"realm": "https://auth.example.com/token"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if parameters := parseChallenge(test.parameters); !reflect.DeepEqual(parameters, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, parameters)
+			}
+		})
+	}
+}
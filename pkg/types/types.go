@@ -1,13 +1,70 @@
 package types
 
 import (
-	helmv1beta1 "github.com/openshift/api/helm/v1beta1"
+	"time"
+
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
 	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type HelmChartEntry struct {
-	Name          string
-	Repository    *helmv1beta1.HelmChartRepository
-	ChartVersions repo.ChartVersions
-	ServerVersion string
+	Name                  string
+	RepositoryName        string
+	RepositoryDisplayName string
+	Namespace             string
+	ChartVersions         repo.ChartVersions
+	ServerVersion         string
+	VersionRetention      *VersionRetention
+	HidePrereleases       bool
+	HideDeprecated        bool
+	Credentials           *ChartCredentials
+}
+
+// ChartCredentials describes the credentials of a repository used to download its charts. Credentials are sent to
+// URLs on the repository host and to URLs on other hosts when PassCredentialsAll is set
+type ChartCredentials struct {
+	// Host is the host of the repository
+	Host string
+
+	// PassCredentialsAll enables sending the credentials to URLs on other hosts
+	PassCredentialsAll bool
+}
+
+// VersionRetention limits the versions of a chart that are synchronized. Zero values do not limit the versions
+type VersionRetention struct {
+	// MaxVersions is the number of most recent versions that are retained
+	MaxVersions int
+
+	// NewerThan excludes versions created before this time. Versions without a creation time are retained
+	NewerThan time.Time
+
+	// LatestPatchOnly retains only the most recent patch version of each minor version
+	LatestPatchOnly bool
+}
+
+// HelmChartObject is implemented by the cluster scoped HelmChart and the namespaced ProjectHelmChart
+type HelmChartObject interface {
+	client.Object
+	GetHelmChartSpec() *redhatcopv1alpha1.HelmChartSpec
+	GetHelmChartStatus() *redhatcopv1alpha1.HelmChartStatus
+	GetConditions() []metav1.Condition
+	SetConditions(conditions []metav1.Condition)
+}
+
+// HelmChartRepositorySyncObject is implemented by the cluster scoped HelmChartRepositorySync and the namespaced
+// ProjectHelmChartRepositorySync
+type HelmChartRepositorySyncObject interface {
+	client.Object
+	GetSyncStatus() *redhatcopv1alpha1.HelmChartRepositorySyncStatus
+	GetConditions() []metav1.Condition
+	SetConditions(conditions []metav1.Condition)
+}
+
+// HelmChartVersionObject is implemented by the cluster scoped HelmChartVersion and the namespaced
+// ProjectHelmChartVersion
+type HelmChartVersionObject interface {
+	client.Object
+	GetHelmChartVersionSpec() *redhatcopv1alpha1.HelmChartVersionSpec
 }
@@ -1,19 +1,32 @@
 package utils
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
 	"github.com/redhat-cop/helm-chart-repository-operator/pkg/types"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/repo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+// invalidNameCharacters matches the characters of a version that are not permitted in resource names
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9.-]`)
+
 const (
-	repositoryLabelKey = "helm-chart-repository-operator.redhat-cop.io/repository"
+	RepositoryLabelKey    = "helm-chart-repository-operator.redhat-cop.io/repository"
+	ChartLabelKey         = "helm-chart-repository-operator.redhat-cop.io/chart"
+	SpecHashAnnotationKey = "helm-chart-repository-operator.redhat-cop.io/spec-hash"
 )
 
 func DefaultCiphers() []uint16 {
@@ -77,48 +90,423 @@ func MapToHelmChart(helmChartEntry *types.HelmChartEntry) (*redhatcopv1alpha1.He
 		},
 	}
 
-	helmChart.Name = fmt.Sprintf("%s.%s", helmChartEntry.Repository.Name, helmChartEntry.Name)
+	err := mapToHelmChartObject(helmChartEntry, helmChart)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return helmChart, nil
+}
+
+// MapToProjectHelmChart maps a chart of a project scoped repository to a chart within the namespace of the repository
+func MapToProjectHelmChart(helmChartEntry *types.HelmChartEntry) (*redhatcopv1alpha1.ProjectHelmChart, error) {
+
+	projectHelmChart := &redhatcopv1alpha1.ProjectHelmChart{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ProjectHelmChart",
+			APIVersion: redhatcopv1alpha1.GroupVersion.String(),
+		},
+	}
+
+	projectHelmChart.Namespace = helmChartEntry.Namespace
+
+	err := mapToHelmChartObject(helmChartEntry, projectHelmChart)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return projectHelmChart, nil
+}
+
+func mapToHelmChartObject(helmChartEntry *types.HelmChartEntry, helmChart types.HelmChartObject) error {
+
+	helmChart.SetName(HelmChartName(helmChartEntry.RepositoryName, helmChartEntry.Name))
 
 	helmChart.SetLabels(map[string]string{
-		repositoryLabelKey: helmChartEntry.Repository.Name,
+		RepositoryLabelKey: helmChartEntry.RepositoryName,
 	})
 
-	if helmChartEntry.Repository.Spec.DisplayName != "" {
-		helmChart.Spec.RepositoryDisplayName = helmChartEntry.Repository.Spec.DisplayName
+	helmChartSpec := helmChart.GetHelmChartSpec()
+
+	if helmChartEntry.RepositoryDisplayName != "" {
+		helmChartSpec.RepositoryDisplayName = helmChartEntry.RepositoryDisplayName
+	}
+
+	helmChartSpec.RepositoryName = helmChartEntry.RepositoryName
+	helmChartSpec.Name = helmChartEntry.Name
+
+	specHash, err := HashHelmChartSpec(helmChartSpec)
+
+	if err != nil {
+		return err
 	}
 
-	helmChart.Spec.RepositoryName = helmChartEntry.Repository.Name
-	helmChart.Spec.Name = helmChartEntry.Name
+	helmChart.SetAnnotations(map[string]string{
+		SpecHashAnnotationKey: specHash,
+	})
+
+	return nil
+}
+
+// MapToHelmChartVersions maps the versions of a chart that are compatible with the server version. Prereleases and
+// deprecated versions are omitted when requested by the entry
+func MapToHelmChartVersions(helmChartEntry *types.HelmChartEntry) ([]redhatcopv1alpha1.HelmChartVersionSpec, error) {
 
-	chartVersions := []redhatcopv1alpha1.HelmChartVersion{}
+	chartVersions := []redhatcopv1alpha1.HelmChartVersionSpec{}
 
-	if helmChartEntry.ChartVersions != nil {
-		for _, chartVersion := range helmChartEntry.ChartVersions {
+	for _, chartVersion := range helmChartEntry.ChartVersions {
+
+		if helmChartEntry.HideDeprecated && chartVersion.Metadata != nil && chartVersion.Deprecated {
+			continue
+		}
+
+		if helmChartEntry.HidePrereleases && chartVersion.Metadata != nil && IsPrerelease(chartVersion.Version) {
+			continue
+		}
+
+		if chartVersion.Metadata != nil && chartVersion.Metadata.KubeVersion != "" && helmChartEntry.ServerVersion != "" {
+			if !chartutil.IsCompatibleRange(chartVersion.Metadata.KubeVersion, helmChartEntry.ServerVersion) {
+				continue
+			}
+		}
+
+		helmChartVersion, err := mapToHelmChartVersion(chartVersion)
+
+		if err != nil {
+			return nil, err
+		}
+
+		helmChartVersion.ChartName = helmChartEntry.Name
+		helmChartVersion.RepositoryName = helmChartEntry.RepositoryName
+		helmChartVersion.PassCredentials = PassCredentials(helmChartEntry.Credentials, chartVersion.URLs)
+
+		chartVersions = append(chartVersions, *helmChartVersion)
+	}
+
+	if helmChartEntry.VersionRetention != nil {
+		chartVersions = applyVersionRetention(chartVersions, helmChartEntry.VersionRetention)
+	}
+
+	return chartVersions, nil
+}
 
-			if chartVersion.Metadata != nil && chartVersion.Metadata.KubeVersion != "" && helmChartEntry.ServerVersion != "" {
-				if !chartutil.IsCompatibleRange(chartVersion.Metadata.KubeVersion, helmChartEntry.ServerVersion) {
+// PassCredentials determines whether the credentials of the repository are sent when downloading a chart. Like Helm,
+// only the first URL of the chart is used to download it
+func PassCredentials(credentials *types.ChartCredentials, chartURLs []string) bool {
+
+	if credentials == nil || len(chartURLs) == 0 {
+		return false
+	}
+
+	if credentials.PassCredentialsAll {
+		return true
+	}
+
+	chartURL, err := url.Parse(chartURLs[0])
+
+	return err == nil && chartURL.Host == credentials.Host
+}
+
+// applyVersionRetention returns the versions retained by the retention settings in their original order. Versions
+// that are not valid semantic versions are ordered after valid versions and are not grouped by minor version
+func applyVersionRetention(helmChartVersions []redhatcopv1alpha1.HelmChartVersionSpec, retention *types.VersionRetention) []redhatcopv1alpha1.HelmChartVersionSpec {
+
+	type candidate struct {
+		index   int
+		version *semver.Version
+	}
+
+	candidates := []candidate{}
+
+	for i, helmChartVersion := range helmChartVersions {
+
+		if !retention.NewerThan.IsZero() && helmChartVersion.Created != nil && helmChartVersion.Created.Time.Before(retention.NewerThan) {
+			continue
+		}
+
+		// Invalid versions are represented by a nil version
+		version, _ := semver.NewVersion(helmChartVersion.Version)
+
+		candidates = append(candidates, candidate{index: i, version: version})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].version == nil || candidates[j].version == nil {
+			return candidates[j].version == nil && candidates[i].version != nil
+		}
+
+		return candidates[i].version.GreaterThan(candidates[j].version)
+	})
+
+	if retention.LatestPatchOnly {
+		minorVersions := map[string]struct{}{}
+		latestPatches := []candidate{}
+
+		for _, c := range candidates {
+			if c.version != nil {
+				minorVersion := fmt.Sprintf("%d.%d", c.version.Major(), c.version.Minor())
+
+				if _, found := minorVersions[minorVersion]; found {
 					continue
 				}
+
+				minorVersions[minorVersion] = struct{}{}
 			}
 
-			helmChartVersion, err := mapToHelmChartVersion(chartVersion)
+			latestPatches = append(latestPatches, c)
+		}
+
+		candidates = latestPatches
+	}
 
-			if err != nil {
-				return nil, err
-			}
+	if retention.MaxVersions > 0 && len(candidates) > retention.MaxVersions {
+		candidates = candidates[:retention.MaxVersions]
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].index < candidates[j].index
+	})
+
+	retainedVersions := []redhatcopv1alpha1.HelmChartVersionSpec{}
+
+	for _, c := range candidates {
+		retainedVersions = append(retainedVersions, helmChartVersions[c.index])
+	}
+
+	return retainedVersions
+}
+
+// MapToHelmChartVersion maps a version of a chart to the resource representing the version
+func MapToHelmChartVersion(helmChartEntry *types.HelmChartEntry, helmChartVersionSpec *redhatcopv1alpha1.HelmChartVersionSpec) (*redhatcopv1alpha1.HelmChartVersion, error) {
+
+	helmChartVersion := &redhatcopv1alpha1.HelmChartVersion{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HelmChartVersion",
+			APIVersion: redhatcopv1alpha1.GroupVersion.String(),
+		},
+	}
+
+	err := mapToHelmChartVersionObject(helmChartEntry, helmChartVersionSpec, helmChartVersion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return helmChartVersion, nil
+}
+
+// MapToProjectHelmChartVersion maps a version of a chart of a project scoped repository to a resource within the
+// namespace of the repository
+func MapToProjectHelmChartVersion(helmChartEntry *types.HelmChartEntry, helmChartVersionSpec *redhatcopv1alpha1.HelmChartVersionSpec) (*redhatcopv1alpha1.ProjectHelmChartVersion, error) {
+
+	projectHelmChartVersion := &redhatcopv1alpha1.ProjectHelmChartVersion{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ProjectHelmChartVersion",
+			APIVersion: redhatcopv1alpha1.GroupVersion.String(),
+		},
+	}
+
+	projectHelmChartVersion.Namespace = helmChartEntry.Namespace
+
+	err := mapToHelmChartVersionObject(helmChartEntry, helmChartVersionSpec, projectHelmChartVersion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return projectHelmChartVersion, nil
+}
+
+func mapToHelmChartVersionObject(helmChartEntry *types.HelmChartEntry, helmChartVersionSpec *redhatcopv1alpha1.HelmChartVersionSpec, helmChartVersion types.HelmChartVersionObject) error {
+
+	helmChartVersion.SetName(HelmChartVersionName(helmChartEntry.RepositoryName, helmChartEntry.Name, helmChartVersionSpec.Version))
+
+	helmChartVersion.SetLabels(map[string]string{
+		RepositoryLabelKey: helmChartEntry.RepositoryName,
+		ChartLabelKey:      helmChartEntry.Name,
+	})
+
+	*helmChartVersion.GetHelmChartVersionSpec() = *helmChartVersionSpec
+
+	specHash, err := HashHelmChartVersionSpec(helmChartVersionSpec)
+
+	if err != nil {
+		return err
+	}
+
+	helmChartVersion.SetAnnotations(map[string]string{
+		SpecHashAnnotationKey: specHash,
+	})
+
+	return nil
+}
+
+// HelmChartVersionName returns the name of the resource representing a version of a chart. Characters that are not
+// permitted in resource names, such as the + separating build metadata, are replaced and a hash of the version is
+// appended so versions differing only in those characters do not collide. Names exceeding the maximum length of
+// resource names are shortened and end with a hash of the full name instead
+func HelmChartVersionName(repositoryName string, chartName string, version string) string {
+
+	sanitizedVersion := invalidNameCharacters.ReplaceAllString(strings.ToLower(version), "-")
+
+	if sanitizedVersion != version {
+		versionHash := fmt.Sprintf("%x", sha256.Sum256([]byte(version)))
+		sanitizedVersion = fmt.Sprintf("%s-%s", sanitizedVersion, versionHash[:8])
+	}
+
+	name := fmt.Sprintf("%s.%s", HelmChartName(repositoryName, chartName), sanitizedVersion)
+
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+
+	// The hash is separated by a dash, so the shortened name must end with an alphanumeric character
+	nameHash := fmt.Sprintf("%x", sha256.Sum256([]byte(HelmChartName(repositoryName, chartName)+"/"+version)))
+	shortenedName := strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-9], ".-")
+
+	return fmt.Sprintf("%s-%s", shortenedName, nameHash[:8])
+}
+
+// HashHelmChartVersionSpec returns a hash of the content of a chart version specification
+func HashHelmChartVersionSpec(helmChartVersionSpec *redhatcopv1alpha1.HelmChartVersionSpec) (string, error) {
+
+	specBytes, err := json.Marshal(helmChartVersionSpec)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(specBytes)), nil
+}
+
+// IsPrerelease determines whether the version is a semantic version with a prerelease part, such as 1.0.0-rc.1
+func IsPrerelease(version string) bool {
+
+	semverVersion, err := semver.NewVersion(version)
 
-			chartVersions = append(chartVersions, *helmChartVersion)
+	return err == nil && semverVersion.Prerelease() != ""
+}
+
+// IsChartDeprecated determines whether a chart is deprecated, which is the case when its latest version is deprecated.
+// The latest version is determined using semantic versioning, falling back to the first version listed by the index
+// when no version is a valid semantic version
+func IsChartDeprecated(chartVersions repo.ChartVersions) bool {
+
+	var latest *repo.ChartVersion
+	var latestSemver *semver.Version
+
+	for _, chartVersion := range chartVersions {
+
+		if chartVersion.Metadata == nil {
+			continue
+		}
+
+		version, err := semver.NewVersion(chartVersion.Version)
+
+		if err != nil {
+			continue
+		}
+
+		if latestSemver == nil || version.GreaterThan(latestSemver) {
+			latest, latestSemver = chartVersion, version
 		}
 	}
 
-	helmChart.Spec.Versions = chartVersions
+	if latest == nil && len(chartVersions) > 0 && chartVersions[0].Metadata != nil {
+		latest = chartVersions[0]
+	}
 
-	return helmChart, nil
+	return latest != nil && latest.Deprecated
+}
+
+// HelmChartName returns the name of the resource representing a chart within a repository
+func HelmChartName(repositoryName string, chartName string) string {
+	return fmt.Sprintf("%s.%s", repositoryName, chartName)
+}
+
+// HashHelmChartSpec returns a hash of the content of a chart specification
+func HashHelmChartSpec(helmChartSpec *redhatcopv1alpha1.HelmChartSpec) (string, error) {
+
+	specBytes, err := json.Marshal(helmChartSpec)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(specBytes)), nil
+}
+
+// SetHelmChartSummary sets the fields of the chart status summarizing its versions and returns whether the status
+// changed. Versions are ordered using semantic versioning and versions that are not valid semantic versions are only
+// considered when the chart contains no valid versions
+func SetHelmChartSummary(helmChartStatus *redhatcopv1alpha1.HelmChartStatus, helmChartVersions []redhatcopv1alpha1.HelmChartVersionSpec) bool {
+
+	var latest, latestStable *redhatcopv1alpha1.HelmChartVersionSpec
+	var latestSemver, latestStableSemver *semver.Version
+	var latestCreated *metav1.Time
+
+	for i := range helmChartVersions {
+		helmChartVersion := &helmChartVersions[i]
+
+		if helmChartVersion.Created != nil && (latestCreated == nil || helmChartVersion.Created.After(latestCreated.Time)) {
+			latestCreated = helmChartVersion.Created
+		}
+
+		version, err := semver.NewVersion(helmChartVersion.Version)
+
+		if err != nil {
+			continue
+		}
+
+		if latestSemver == nil || version.GreaterThan(latestSemver) {
+			latest, latestSemver = helmChartVersion, version
+		}
+
+		if version.Prerelease() == "" && (latestStableSemver == nil || version.GreaterThan(latestStableSemver)) {
+			latestStable, latestStableSemver = helmChartVersion, version
+		}
+	}
+
+	// Repository indexes list the newest version first
+	if latest == nil && len(helmChartVersions) > 0 {
+		latest = &helmChartVersions[0]
+	}
+
+	summary := redhatcopv1alpha1.HelmChartStatus{
+		VersionCount:  len(helmChartVersions),
+		LatestCreated: latestCreated,
+	}
+
+	if latest != nil {
+		summary.LatestVersion = latest.Version
+		summary.LatestAppVersion = latest.AppVersion
+	}
+
+	if latestStable != nil {
+		summary.LatestStableVersion = latestStable.Version
+		summary.LatestAppVersion = latestStable.AppVersion
+	}
+
+	changed := helmChartStatus.LatestVersion != summary.LatestVersion ||
+		helmChartStatus.LatestStableVersion != summary.LatestStableVersion ||
+		helmChartStatus.LatestAppVersion != summary.LatestAppVersion ||
+		helmChartStatus.VersionCount != summary.VersionCount ||
+		!helmChartStatus.LatestCreated.Equal(summary.LatestCreated)
+
+	helmChartStatus.LatestVersion = summary.LatestVersion
+	helmChartStatus.LatestStableVersion = summary.LatestStableVersion
+	helmChartStatus.LatestAppVersion = summary.LatestAppVersion
+	helmChartStatus.VersionCount = summary.VersionCount
+	helmChartStatus.LatestCreated = summary.LatestCreated
+
+	return changed
 }
 
-func mapToHelmChartVersion(chartVersion *repo.ChartVersion) (*redhatcopv1alpha1.HelmChartVersion, error) {
+func mapToHelmChartVersion(chartVersion *repo.ChartVersion) (*redhatcopv1alpha1.HelmChartVersionSpec, error) {
 
-	helmChartVersion := &redhatcopv1alpha1.HelmChartVersion{}
+	helmChartVersion := &redhatcopv1alpha1.HelmChartVersionSpec{}
 	helmChartVersion.ApiVersion = chartVersion.APIVersion
 	helmChartVersion.AppVersion = chartVersion.AppVersion
 
@@ -169,6 +557,7 @@ func mapToHelmChartVersion(chartVersion *repo.ChartVersion) (*redhatcopv1alpha1.
 	helmChartVersion.Type = chartVersion.Type
 	helmChartVersion.URLs = chartVersion.URLs
 	helmChartVersion.Version = chartVersion.Version
+	helmChartVersion.Deprecated = chartVersion.Deprecated
 
 	return helmChartVersion, nil
 
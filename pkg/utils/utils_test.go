@@ -0,0 +1,348 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	redhatcopv1alpha1 "github.com/redhat-cop/helm-chart-repository-operator/api/v1alpha1"
+	"github.com/redhat-cop/helm-chart-repository-operator/pkg/types"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestApplyVersionRetention(t *testing.T) {
+
+	now := time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	// Versions are listed in index order, which is not necessarily the semantic version order
+	helmChartVersions := []redhatcopv1alpha1.HelmChartVersionSpec{
+		{Version: "1.2.0", Created: &metav1.Time{Time: now.AddDate(0, 0, -10)}},
+		{Version: "2.0.0", Created: &metav1.Time{Time: now.AddDate(0, 0, -1)}},
+		{Version: "1.2.1", Created: &metav1.Time{Time: now.AddDate(0, 0, -5)}},
+		{Version: "latest"},
+		{Version: "1.1.0", Created: &metav1.Time{Time: now.AddDate(0, 0, -30)}},
+		{Version: "1.10.0", Created: &metav1.Time{Time: now.AddDate(0, 0, -3)}},
+	}
+
+	tests := []struct {
+		name      string
+		retention *types.VersionRetention
+		expected  []string
+	}{
+		{
+			name:      "no limits",
+			retention: &types.VersionRetention{},
+			expected:  []string{"1.2.0", "2.0.0", "1.2.1", "latest", "1.1.0", "1.10.0"},
+		},
+		{
+			name:      "maximum versions",
+			retention: &types.VersionRetention{MaxVersions: 3},
+			expected:  []string{"2.0.0", "1.2.1", "1.10.0"},
+		},
+		{
+			name:      "maximum versions exceeding the number of versions",
+			retention: &types.VersionRetention{MaxVersions: 10},
+			expected:  []string{"1.2.0", "2.0.0", "1.2.1", "latest", "1.1.0", "1.10.0"},
+		},
+		{
+			name:      "invalid versions are ordered last",
+			retention: &types.VersionRetention{MaxVersions: 5},
+			expected:  []string{"1.2.0", "2.0.0", "1.2.1", "1.1.0", "1.10.0"},
+		},
+		{
+			name:      "newer than",
+			retention: &types.VersionRetention{NewerThan: now.AddDate(0, 0, -7)},
+			expected:  []string{"2.0.0", "1.2.1", "latest", "1.10.0"},
+		},
+		{
+			name:      "latest patch only",
+			retention: &types.VersionRetention{LatestPatchOnly: true},
+			expected:  []string{"2.0.0", "1.2.1", "latest", "1.1.0", "1.10.0"},
+		},
+		{
+			name:      "all limits",
+			retention: &types.VersionRetention{MaxVersions: 2, NewerThan: now.AddDate(0, 0, -20), LatestPatchOnly: true},
+			expected:  []string{"2.0.0", "1.10.0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			versions := []string{}
+			for _, helmChartVersion := range applyVersionRetention(helmChartVersions, test.retention) {
+				versions = append(versions, helmChartVersion.Version)
+			}
+
+			if !reflect.DeepEqual(versions, test.expected) {
+				t.Errorf("expected versions %v, got %v", test.expected, versions)
+			}
+		})
+	}
+}
+
+func TestPassCredentials(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		credentials *types.ChartCredentials
+		chartURLs   []string
+		expected    bool
+	}{
+		{name: "no credentials", chartURLs: []string{"https://charts.example.com/nginx-1.0.0.tgz"}, expected: false},
+		{name: "no chart URLs", credentials: &types.ChartCredentials{Host: "charts.example.com"}, expected: false},
+		{name: "repository host", credentials: &types.ChartCredentials{Host: "charts.example.com"}, chartURLs: []string{"https://charts.example.com/nginx-1.0.0.tgz"}, expected: true},
+		{name: "other host", credentials: &types.ChartCredentials{Host: "charts.example.com"}, chartURLs: []string{"https://cdn.example.com/nginx-1.0.0.tgz"}, expected: false},
+		{name: "other host passing credentials", credentials: &types.ChartCredentials{Host: "charts.example.com", PassCredentialsAll: true}, chartURLs: []string{"https://cdn.example.com/nginx-1.0.0.tgz"}, expected: true},
+		{name: "only the first URL is used", credentials: &types.ChartCredentials{Host: "charts.example.com"}, chartURLs: []string{"https://cdn.example.com/nginx-1.0.0.tgz", "https://charts.example.com/nginx-1.0.0.tgz"}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if passCredentials := PassCredentials(test.credentials, test.chartURLs); passCredentials != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, passCredentials)
+			}
+		})
+	}
+}
+
+func TestSetHelmChartSummary(t *testing.T) {
+
+	created := time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		helmChartVersions []redhatcopv1alpha1.HelmChartVersionSpec
+		expected          redhatcopv1alpha1.HelmChartStatus
+	}{
+		{
+			name:              "no versions",
+			helmChartVersions: []redhatcopv1alpha1.HelmChartVersionSpec{},
+			expected:          redhatcopv1alpha1.HelmChartStatus{},
+		},
+		{
+			name: "semantic version order",
+			helmChartVersions: []redhatcopv1alpha1.HelmChartVersionSpec{
+				{Version: "1.2.0", AppVersion: "1.2", Created: &metav1.Time{Time: created.AddDate(0, 0, -10)}},
+				{Version: "1.10.0", AppVersion: "1.10", Created: &metav1.Time{Time: created.AddDate(0, 0, -5)}},
+				{Version: "1.9.0", AppVersion: "1.9", Created: &metav1.Time{Time: created}},
+			},
+			expected: redhatcopv1alpha1.HelmChartStatus{
+				LatestVersion:       "1.10.0",
+				LatestStableVersion: "1.10.0",
+				LatestAppVersion:    "1.10",
+				VersionCount:        3,
+				LatestCreated:       &metav1.Time{Time: created},
+			},
+		},
+		{
+			name: "prerelease newer than the latest stable version",
+			helmChartVersions: []redhatcopv1alpha1.HelmChartVersionSpec{
+				{Version: "2.0.0-rc.1", AppVersion: "2.0-rc"},
+				{Version: "1.5.0", AppVersion: "1.5"},
+			},
+			expected: redhatcopv1alpha1.HelmChartStatus{
+				LatestVersion:       "2.0.0-rc.1",
+				LatestStableVersion: "1.5.0",
+				LatestAppVersion:    "1.5",
+				VersionCount:        2,
+			},
+		},
+		{
+			name: "only prereleases",
+			helmChartVersions: []redhatcopv1alpha1.HelmChartVersionSpec{
+				{Version: "1.0.0-alpha.1", AppVersion: "1.0-alpha"},
+				{Version: "1.0.0-beta.1", AppVersion: "1.0-beta"},
+			},
+			expected: redhatcopv1alpha1.HelmChartStatus{
+				LatestVersion:    "1.0.0-beta.1",
+				LatestAppVersion: "1.0-beta",
+				VersionCount:     2,
+			},
+		},
+		{
+			name: "versions that are not semantic versions",
+			helmChartVersions: []redhatcopv1alpha1.HelmChartVersionSpec{
+				{Version: "latest", AppVersion: "main"},
+				{Version: "stable", AppVersion: "release"},
+			},
+			expected: redhatcopv1alpha1.HelmChartStatus{
+				LatestVersion:    "latest",
+				LatestAppVersion: "main",
+				VersionCount:     2,
+			},
+		},
+		{
+			name: "semantic versions take precedence over other versions",
+			helmChartVersions: []redhatcopv1alpha1.HelmChartVersionSpec{
+				{Version: "latest", AppVersion: "main"},
+				{Version: "1.0.0", AppVersion: "1.0"},
+			},
+			expected: redhatcopv1alpha1.HelmChartStatus{
+				LatestVersion:       "1.0.0",
+				LatestStableVersion: "1.0.0",
+				LatestAppVersion:    "1.0",
+				VersionCount:        2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			status := &redhatcopv1alpha1.HelmChartStatus{}
+
+			// A summary of no versions matches an empty status
+			expectedChanged := len(test.helmChartVersions) > 0
+
+			if changed := SetHelmChartSummary(status, test.helmChartVersions); changed != expectedChanged {
+				t.Errorf("expected changed to be %t, got %t", expectedChanged, changed)
+			}
+
+			if !reflect.DeepEqual(*status, test.expected) {
+				t.Errorf("expected summary %+v, got %+v", test.expected, *status)
+			}
+
+			if SetHelmChartSummary(status, test.helmChartVersions) {
+				t.Error("expected the summary to be unchanged")
+			}
+		})
+	}
+}
+
+func TestHelmChartVersionName(t *testing.T) {
+
+	longChartName := strings.Repeat("chart", 50)
+	longVersion := "1.0.0-" + strings.Repeat("rc", 150)
+
+	tests := []struct {
+		name           string
+		chartName      string
+		version        string
+		expected       string
+		expectedPrefix string
+	}{
+		{name: "valid version", chartName: "nginx", version: "1.0.0", expected: "repository.nginx.1.0.0"},
+		{name: "build metadata", chartName: "nginx", version: "1.0.0+build.1", expectedPrefix: "repository.nginx.1.0.0-build.1-"},
+		{name: "uppercase version", chartName: "nginx", version: "1.0.0-RC.1", expectedPrefix: "repository.nginx.1.0.0-rc.1-"},
+		{name: "long chart name", chartName: longChartName, version: "1.0.0", expectedPrefix: "repository." + longChartName[:200]},
+		{name: "long version", chartName: "nginx", version: longVersion, expectedPrefix: "repository.nginx.1.0.0-rcrc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			name := HelmChartVersionName("repository", test.chartName, test.version)
+
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				t.Errorf("expected a valid resource name, got %q: %v", name, errs)
+			}
+
+			if test.expected != "" && name != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, name)
+			}
+
+			if !strings.HasPrefix(name, test.expectedPrefix) {
+				t.Errorf("expected a name starting with %q, got %q", test.expectedPrefix, name)
+			}
+
+			if repeated := HelmChartVersionName("repository", test.chartName, test.version); repeated != name {
+				t.Errorf("expected the name to be deterministic, got %q and %q", name, repeated)
+			}
+		})
+	}
+
+	distinctVersions := [][]string{
+		{"1.0.0+build.1", "1.0.0+build.2"},
+		{"1.0.0+build.1", "1.0.0-build.1"},
+		{"1.0.0-RC.1", "1.0.0-rc.1"},
+		{longVersion + "a", longVersion + "b"},
+	}
+
+	for _, versions := range distinctVersions {
+		if HelmChartVersionName("repository", "nginx", versions[0]) == HelmChartVersionName("repository", "nginx", versions[1]) {
+			t.Errorf("expected distinct names for versions %s and %s", versions[0], versions[1])
+		}
+	}
+}
+
+// newChartVersion returns an index entry of the nginx chart
+func newChartVersion(version string, deprecated bool) *repo.ChartVersion {
+	return &repo.ChartVersion{
+		Metadata: &chart.Metadata{Name: "nginx", Version: version, Deprecated: deprecated},
+		URLs:     []string{"https://charts.example.com/nginx-" + version + ".tgz"},
+	}
+}
+
+func TestMapToHelmChartVersionsVisibility(t *testing.T) {
+
+	chartVersions := repo.ChartVersions{
+		newChartVersion("2.0.0-rc.1", false),
+		newChartVersion("1.1.0", true),
+		newChartVersion("1.0.0", false),
+	}
+
+	tests := []struct {
+		name            string
+		hidePrereleases bool
+		hideDeprecated  bool
+		expected        []string
+	}{
+		{name: "all versions", expected: []string{"2.0.0-rc.1", "1.1.0", "1.0.0"}},
+		{name: "hide prereleases", hidePrereleases: true, expected: []string{"1.1.0", "1.0.0"}},
+		{name: "hide deprecated versions", hideDeprecated: true, expected: []string{"2.0.0-rc.1", "1.0.0"}},
+		{name: "hide prereleases and deprecated versions", hidePrereleases: true, hideDeprecated: true, expected: []string{"1.0.0"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			helmChartVersions, err := MapToHelmChartVersions(&types.HelmChartEntry{
+				Name:            "nginx",
+				RepositoryName:  "repository",
+				ChartVersions:   chartVersions,
+				HidePrereleases: test.hidePrereleases,
+				HideDeprecated:  test.hideDeprecated,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			versions := []string{}
+			for _, helmChartVersion := range helmChartVersions {
+				versions = append(versions, helmChartVersion.Version)
+			}
+
+			if !reflect.DeepEqual(versions, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, versions)
+			}
+		})
+	}
+}
+
+func TestIsChartDeprecated(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		chartVersions repo.ChartVersions
+		expected      bool
+	}{
+		{name: "no versions", chartVersions: repo.ChartVersions{}, expected: false},
+		{name: "latest version deprecated", chartVersions: repo.ChartVersions{newChartVersion("1.0.0", false), newChartVersion("1.1.0", true)}, expected: true},
+		{name: "earlier version deprecated", chartVersions: repo.ChartVersions{newChartVersion("1.1.0", false), newChartVersion("1.0.0", true)}, expected: false},
+		{name: "prerelease is the latest version", chartVersions: repo.ChartVersions{newChartVersion("1.0.0", false), newChartVersion("1.1.0-rc.1", true)}, expected: true},
+		{name: "invalid versions", chartVersions: repo.ChartVersions{newChartVersion("latest", true), newChartVersion("stable", false)}, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if deprecated := IsChartDeprecated(test.chartVersions); deprecated != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, deprecated)
+			}
+		})
+	}
+}